@@ -26,6 +26,18 @@ var (
 	}
 )
 
+// Metadata of a RabbitMQ delivery.
+type Metadata struct {
+	Exchange      string
+	RoutingKey    string
+	DeliveryTag   uint64
+	Redelivered   bool
+	MessageID     string
+	CorrelationID string
+	ContentType   string
+	Timestamp     time.Time
+}
+
 type message struct {
 	span    opentracing.Span
 	ctx     context.Context
@@ -42,6 +54,32 @@ func (m *message) Decode(v interface{}) error {
 	return m.dec(m.del.Body, v)
 }
 
+// Body returns the raw body of the delivery.
+func (m *message) Body() []byte {
+	return m.del.Body
+}
+
+// Headers returns the headers of the delivery, along with its content type.
+func (m *message) Headers() map[string]string {
+	hh := mapHeader(m.del.Headers)
+	hh[encoding.ContentTypeHeader] = m.del.ContentType
+	return hh
+}
+
+// Metadata returns the Metadata of the delivery.
+func (m *message) Metadata() interface{} {
+	return Metadata{
+		Exchange:      m.del.Exchange,
+		RoutingKey:    m.del.RoutingKey,
+		DeliveryTag:   m.del.DeliveryTag,
+		Redelivered:   m.del.Redelivered,
+		MessageID:     m.del.MessageId,
+		CorrelationID: m.del.CorrelationId,
+		ContentType:   m.del.ContentType,
+		Timestamp:     m.del.Timestamp,
+	}
+}
+
 func (m *message) Ack() error {
 	err := m.del.Ack(false)
 	trace.SpanSuccess(m.span)
@@ -116,6 +154,8 @@ type consumer struct {
 	cfg      amqp.Config
 	ch       *amqp.Channel
 	conn     *amqp.Connection
+	closed   chan *amqp.Error
+	connErr  error
 	info     map[string]interface{}
 }
 
@@ -185,12 +225,34 @@ func (c *consumer) Close() error {
 	return errors.Aggregate(errChan, errConn)
 }
 
+// Check reports whether the connection to the broker is still open.
+func (c *consumer) Check(ctx context.Context) error {
+	if c.conn == nil {
+		return errors.New("consumer is not connected")
+	}
+	if c.connErr != nil {
+		return c.connErr
+	}
+	select {
+	case amqpErr := <-c.closed:
+		if amqpErr != nil {
+			c.connErr = errors.Wrap(amqpErr, "connection is closed")
+		} else {
+			c.connErr = errors.New("connection is closed")
+		}
+		return c.connErr
+	default:
+		return nil
+	}
+}
+
 func (c *consumer) consume() (<-chan amqp.Delivery, error) {
 	conn, err := amqp.DialConfig(c.url, c.cfg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to dial @ %s", c.url)
 	}
 	c.conn = conn
+	c.closed = conn.NotifyClose(make(chan *amqp.Error, 1))
 
 	ch, err := c.conn.Channel()
 	if err != nil {
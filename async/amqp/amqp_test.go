@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/mantzas/patron/encoding"
 	"github.com/mantzas/patron/encoding/json"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
@@ -15,7 +16,14 @@ func Test_message(t *testing.T) {
 	b, err := json.Encode("test")
 	assert.NoError(t, err)
 	del := &amqp.Delivery{
-		Body: b,
+		Body:        b,
+		ContentType: json.Type,
+		Headers:     amqp.Table{"key": "value"},
+		Exchange:    "exchange",
+		RoutingKey:  "key",
+		DeliveryTag: 1,
+		Redelivered: true,
+		MessageId:   "id",
 	}
 	mtr := mocktracer.New()
 	opentracing.SetGlobalTracer(mtr)
@@ -31,6 +39,9 @@ func Test_message(t *testing.T) {
 	var data string
 	assert.NoError(t, m.Decode(&data))
 	assert.Equal(t, "test", data)
+	assert.Equal(t, b, m.Body())
+	assert.Equal(t, map[string]string{"key": "value", encoding.ContentTypeHeader: json.Type}, m.Headers())
+	assert.Equal(t, Metadata{Exchange: "exchange", RoutingKey: "key", DeliveryTag: 1, Redelivered: true, MessageID: "id", ContentType: json.Type}, m.Metadata())
 	assert.Error(t, m.Ack())
 	assert.Error(t, m.Nack())
 }
@@ -119,3 +130,18 @@ func TestConsumer_Info(t *testing.T) {
 	expected["url"] = "url"
 	assert.Equal(t, expected, c.Info())
 }
+
+func TestConsumer_Check(t *testing.T) {
+	f, err := New("url", "queue", "exchange")
+	assert.NoError(t, err)
+	cns, err := f.Create()
+	assert.NoError(t, err)
+	c := cns.(*consumer)
+	assert.Error(t, c.Check(context.Background()))
+	c.conn = &amqp.Connection{}
+	c.closed = make(chan *amqp.Error, 1)
+	assert.NoError(t, c.Check(context.Background()))
+	c.closed <- &amqp.Error{Reason: "TEST"}
+	assert.Error(t, c.Check(context.Background()))
+	assert.Error(t, c.Check(context.Background()))
+}
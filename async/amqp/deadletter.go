@@ -0,0 +1,45 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/mantzas/patron/async"
+	"github.com/mantzas/patron/encoding"
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/trace/amqp"
+)
+
+// DeadLetterPublisher publishes dead letters to exchanges with RabbitMQ publishers.
+type DeadLetterPublisher struct {
+	pp map[string]amqp.Publisher
+}
+
+// NewDeadLetterPublisher creates a dead letter publisher with a publisher for each destination,
+// which is usually the exchange the publisher was created for.
+func NewDeadLetterPublisher(pp map[string]amqp.Publisher) (*DeadLetterPublisher, error) {
+	if len(pp) == 0 {
+		return nil, errors.New("publishers are required")
+	}
+	for dest, p := range pp {
+		if p == nil {
+			return nil, errors.Errorf("publisher of %s is nil", dest)
+		}
+	}
+	return &DeadLetterPublisher{pp: pp}, nil
+}
+
+// Publish publishes the dead letter with the publisher of the destination.
+// The content type of the dead letter is taken from its content type header.
+func (dp *DeadLetterPublisher) Publish(ctx context.Context, dest string, dl *async.DeadLetterMessage) error {
+	p, ok := dp.pp[dest]
+	if !ok {
+		return errors.Errorf("no publisher for destination %s", dest)
+	}
+	hh := make(map[string]string, len(dl.Headers))
+	for k, v := range dl.Headers {
+		hh[k] = v
+	}
+	ct := hh[encoding.ContentTypeHeader]
+	delete(hh, encoding.ContentTypeHeader)
+	return p.Publish(ctx, amqp.NewMessageWithHeaders(ct, dl.Body, hh))
+}
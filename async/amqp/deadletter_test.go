@@ -0,0 +1,81 @@
+package amqp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mantzas/patron/async"
+	"github.com/mantzas/patron/encoding"
+	"github.com/mantzas/patron/encoding/json"
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/trace/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPublisher struct {
+	retError bool
+	msg      *amqp.Message
+}
+
+func (mp *mockPublisher) Publish(_ context.Context, msg *amqp.Message) error {
+	if mp.retError {
+		return errors.New("PUBLISH ERROR")
+	}
+	mp.msg = msg
+	return nil
+}
+
+func (mp *mockPublisher) Close(_ context.Context) error {
+	return nil
+}
+
+func TestNewDeadLetterPublisher(t *testing.T) {
+	tests := []struct {
+		name    string
+		pp      map[string]amqp.Publisher
+		wantErr bool
+	}{
+		{name: "success", pp: map[string]amqp.Publisher{"dlq": &mockPublisher{}}, wantErr: false},
+		{name: "missing publishers", pp: nil, wantErr: true},
+		{name: "nil publisher", pp: map[string]amqp.Publisher{"dlq": nil}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDeadLetterPublisher(tt.pp)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestDeadLetterPublisher_Publish(t *testing.T) {
+	tests := []struct {
+		name    string
+		dest    string
+		pub     *mockPublisher
+		wantErr bool
+	}{
+		{name: "success", dest: "dlq", pub: &mockPublisher{}, wantErr: false},
+		{name: "unknown destination", dest: "unknown", pub: &mockPublisher{}, wantErr: true},
+		{name: "publish failure", dest: "dlq", pub: &mockPublisher{retError: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewDeadLetterPublisher(map[string]amqp.Publisher{"dlq": tt.pub})
+			assert.NoError(t, err)
+			dl := &async.DeadLetterMessage{Body: []byte("body"), Headers: map[string]string{"key": "value", encoding.ContentTypeHeader: json.Type}}
+			err = p.Publish(context.Background(), tt.dest, dl)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, amqp.NewMessageWithHeaders(json.Type, []byte("body"), map[string]string{"key": "value"}), tt.pub.msg)
+			}
+		})
+	}
+}
@@ -13,11 +13,16 @@ import (
 type ProcessorFunc func(Message) error
 
 // Message interface for defining messages that are handled by the async component.
+// Besides decoding, messages expose their raw body, their headers and the metadata of their source,
+// e.g. the kafka.Metadata or amqp.Metadata of the consumer packages, which can be retrieved with a type assertion.
 type Message interface {
 	Context() context.Context
 	Decode(v interface{}) error
 	Ack() error
 	Nack() error
+	Body() []byte
+	Headers() map[string]string
+	Metadata() interface{}
 }
 
 // ConsumerFactory interface for creating consumers.
@@ -32,6 +37,12 @@ type Consumer interface {
 	Info() map[string]interface{}
 }
 
+// HealthChecker interface which consumers can implement in order to report the health of their connection
+// to the readiness check of the async component.
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
 // DetermineDecoder determines the decoder based on the content type.
 func DetermineDecoder(contentType string) (encoding.DecodeRawFunc, error) {
 	switch contentType {
@@ -0,0 +1,201 @@
+package async
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+)
+
+const (
+	defaultBatchSize   = 100
+	defaultBatchLinger = time.Second
+)
+
+// BatchProcessorFunc definition of a async processor which processes messages in batches.
+type BatchProcessorFunc func([]Message) error
+
+// AckMode type definition, which defines how the messages of a batch are acknowledged.
+type AckMode int
+
+const (
+	// BatchAck acknowledges all messages of the batch when the processor succeeds,
+	// or executes the failure strategy for all of them when it fails.
+	BatchAck AckMode = 0
+	// MessageAck leaves acknowledging each message of the batch to the processor.
+	// A error returned by the processor is handled by the failure strategy for the messages,
+	// which the processor has neither acknowledged nor not acknowledged, while retries process only those messages.
+	MessageAck AckMode = 1
+)
+
+func (am AckMode) String() string {
+	switch am {
+	case BatchAck:
+		return "BatchAck"
+	case MessageAck:
+		return "MessageAck"
+	default:
+		return "N/A"
+	}
+}
+
+// NewBatch returns a new async component which processes messages in batches.
+// A batch is processed when it reaches the batch size or when the linger time has passed since its first message.
+// The default batch size is 100 messages and the default linger time one second.
+// Use options to change the default behavior.
+func NewBatch(name string, p BatchProcessorFunc, cf ConsumerFactory, oo ...OptionFunc) (*Component, error) {
+	if p == nil {
+		return nil, errors.New("batch processor is required")
+	}
+	return newComponent(name, cf, func(c *Component) { c.batchProc = p }, oo...)
+}
+
+func (c *Component) processBatch(ctx context.Context, mm []Message, ch chan error) {
+	for _, msg := range mm {
+		if !awaitRetry(ctx, msg) {
+			return
+		}
+	}
+	pp := mm
+	if c.batchAck == MessageAck {
+		pp = resolvable(mm)
+	}
+	err := c.processWithRetry(ctx, pp, func() error {
+		batch := unresolved(pp)
+		if len(batch) == 0 {
+			return nil
+		}
+		return c.observe(func() error {
+			return c.recoverPanic(batch, func() error {
+				return c.batchProc(batch)
+			})
+		})
+	})
+	if err == errRetryCanceled {
+		return
+	}
+	if c.batchAck == MessageAck {
+		if err == nil {
+			return
+		}
+		uu := unresolved(pp)
+		c.failed(len(uu))
+		log.Errorf("failed to process batch of %d messages: %v", len(mm), err)
+		for _, msg := range uu {
+			fErr := c.executeFailureStrategy(msg, err)
+			if fErr != nil {
+				fail(ch, fErr)
+			}
+		}
+		return
+	}
+	if err != nil {
+		c.failed(len(mm))
+		for _, msg := range mm {
+			fErr := c.executeFailureStrategy(msg, err)
+			if fErr != nil {
+				fail(ch, fErr)
+			}
+		}
+		return
+	}
+	for _, msg := range mm {
+		if err := msg.Ack(); err != nil {
+			fail(ch, err)
+		}
+	}
+}
+
+// resolvableMessage records whether the processor has acknowledged or not acknowledged the message,
+// which is resolved only once, so that e.g. a retry or a cancellation does not acknowledge it again.
+type resolvableMessage struct {
+	Message
+	done int32
+}
+
+func resolvable(mm []Message) []Message {
+	rr := make([]Message, len(mm))
+	for i, msg := range mm {
+		rr[i] = &resolvableMessage{Message: msg}
+	}
+	return rr
+}
+
+// unresolved returns the messages which have not been resolved.
+func unresolved(mm []Message) []Message {
+	uu := make([]Message, 0, len(mm))
+	for _, msg := range mm {
+		if rm, ok := msg.(*resolvableMessage); ok && rm.resolved() {
+			continue
+		}
+		uu = append(uu, msg)
+	}
+	return uu
+}
+
+// Ack acknowledges the message and marks it as resolved, unless it has already been resolved.
+func (rm *resolvableMessage) Ack() error {
+	if !atomic.CompareAndSwapInt32(&rm.done, 0, 1) {
+		return nil
+	}
+	return rm.Message.Ack()
+}
+
+// Nack does not acknowledge the message and marks it as resolved, unless it has already been resolved.
+func (rm *resolvableMessage) Nack() error {
+	if !atomic.CompareAndSwapInt32(&rm.done, 0, 1) {
+		return nil
+	}
+	return rm.Message.Nack()
+}
+
+func (rm *resolvableMessage) resolved() bool {
+	return atomic.LoadInt32(&rm.done) == 1
+}
+
+// batcher collects messages into batches of a max size, which expire after the linger time
+// has passed since their first message.
+type batcher struct {
+	size   int
+	linger time.Duration
+	mm     []Message
+	timer  *time.Timer
+}
+
+func newBatcher(size int, linger time.Duration) *batcher {
+	return &batcher{size: size, linger: linger}
+}
+
+// add appends the message to the batch and reports whether the batch is full.
+func (b *batcher) add(msg Message) bool {
+	if len(b.mm) == 0 {
+		b.timer = time.NewTimer(b.linger)
+	}
+	b.mm = append(b.mm, msg)
+	return len(b.mm) >= b.size
+}
+
+// expired returns a channel which fires when the linger time of the current batch has passed.
+func (b *batcher) expired() <-chan time.Time {
+	if b.timer == nil {
+		return nil
+	}
+	return b.timer.C
+}
+
+// flush returns the current batch and starts a new one.
+func (b *batcher) flush() []Message {
+	b.stop()
+	mm := b.mm
+	b.mm = nil
+	return mm
+}
+
+func (b *batcher) stop() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+}
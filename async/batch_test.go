@@ -0,0 +1,294 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/reliability/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingMessage struct {
+	mockMessage
+	sync.Mutex
+	acked  bool
+	nacked bool
+	calls  int
+}
+
+func (rm *recordingMessage) Ack() error {
+	rm.Lock()
+	defer rm.Unlock()
+	rm.acked = true
+	rm.calls++
+	return nil
+}
+
+func (rm *recordingMessage) Nack() error {
+	rm.Lock()
+	defer rm.Unlock()
+	rm.nacked = true
+	rm.calls++
+	return nil
+}
+
+func (rm *recordingMessage) state() (bool, bool) {
+	rm.Lock()
+	defer rm.Unlock()
+	return rm.acked, rm.nacked
+}
+
+func TestNewBatch(t *testing.T) {
+	bp := func([]Message) error { return nil }
+	type args struct {
+		p  BatchProcessorFunc
+		cf ConsumerFactory
+		oo []OptionFunc
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{name: "success", args: args{p: bp, cf: &mockConsumerFactory{}, oo: []OptionFunc{BatchSize(10), BatchLinger(time.Second), BatchAckMode(MessageAck)}}, wantErr: false},
+		{name: "failed, missing processor", args: args{p: nil, cf: &mockConsumerFactory{}}, wantErr: true},
+		{name: "failed, missing consumer", args: args{p: bp, cf: nil}, wantErr: true},
+		{name: "failed, ordered", args: args{p: bp, cf: &mockConsumerFactory{}, oo: []OptionFunc{OrderedBy(func(Message) string { return "" })}}, wantErr: true},
+		{name: "success, max in-flight", args: args{p: bp, cf: &mockConsumerFactory{}, oo: []OptionFunc{BatchSize(10), BatchLinger(time.Second), BatchAckMode(MessageAck), MaxInFlight(10)}}, wantErr: false},
+		{name: "failed, max in-flight less than batch size", args: args{p: bp, cf: &mockConsumerFactory{}, oo: []OptionFunc{BatchSize(10), MaxInFlight(5)}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBatch("test", tt.args.p, tt.args.cf, tt.args.oo...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 10, got.Info()["batch-size"])
+				assert.Equal(t, "1s", got.Info()["batch-linger"])
+				assert.Equal(t, "MessageAck", got.Info()["batch-ack"])
+			}
+		})
+	}
+}
+
+func TestComponent_processBatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		am         AckMode
+		fs         FailStrategy
+		procErr    bool
+		wantAcked  bool
+		wantNacked bool
+		wantErr    bool
+	}{
+		{"batch ack success", BatchAck, NackExitStrategy, false, true, false, false},
+		{"batch ack failure with nack exit", BatchAck, NackExitStrategy, true, false, true, true},
+		{"batch ack failure with nack", BatchAck, NackStrategy, true, false, true, false},
+		{"batch ack failure with ack", BatchAck, AckStrategy, true, true, false, false},
+		{"message ack success", MessageAck, NackExitStrategy, false, false, false, false},
+		{"message ack failure with nack exit", MessageAck, NackExitStrategy, true, false, true, true},
+		{"message ack failure with nack", MessageAck, NackStrategy, true, false, true, false},
+		{"message ack failure with ack", MessageAck, AckStrategy, true, true, false, false},
+		{"message ack failure with dead letter", MessageAck, DeadLetterStrategy, true, true, false, false},
+		{"batch ack failure with dead letter", BatchAck, DeadLetterStrategy, true, true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			procErr := tt.procErr
+			bp := func([]Message) error {
+				if procErr {
+					return errors.New("PROC ERROR")
+				}
+				return nil
+			}
+			pub := &mockPublisher{}
+			fo := FailureStrategy(tt.fs)
+			if tt.fs == DeadLetterStrategy {
+				fo = DeadLetter(pub, "dlq")
+			}
+			c, err := NewBatch("test", bp, &mockConsumerFactory{}, BatchAckMode(tt.am), fo)
+			assert.NoError(t, err)
+			mm := []*recordingMessage{{mockMessage: mockMessage{ctx: context.Background()}}, {mockMessage: mockMessage{ctx: context.Background()}}}
+			ch := make(chan error, 1)
+			c.processBatch(context.Background(), []Message{mm[0], mm[1]}, ch)
+			for _, m := range mm {
+				acked, nacked := m.state()
+				assert.Equal(t, tt.wantAcked, acked)
+				assert.Equal(t, tt.wantNacked, nacked)
+			}
+			if tt.wantErr {
+				assert.Error(t, <-ch)
+			} else {
+				assert.Len(t, ch, 0)
+			}
+			if tt.fs == DeadLetterStrategy {
+				assert.Equal(t, "dlq", pub.dest)
+			}
+		})
+	}
+}
+
+func TestComponent_processBatch_MessageAckPartial(t *testing.T) {
+	bp := func(mm []Message) error {
+		assert.NoError(t, mm[0].Ack())
+		return errors.New("PROC ERROR")
+	}
+	c, err := NewBatch("test", bp, &mockConsumerFactory{}, BatchAckMode(MessageAck), FailureStrategy(NackStrategy))
+	assert.NoError(t, err)
+	mm := []*recordingMessage{{mockMessage: mockMessage{ctx: context.Background()}}, {mockMessage: mockMessage{ctx: context.Background()}}}
+	ch := make(chan error, 1)
+	c.processBatch(context.Background(), []Message{mm[0], mm[1]}, ch)
+	acked, nacked := mm[0].state()
+	assert.True(t, acked)
+	assert.False(t, nacked)
+	acked, nacked = mm[1].state()
+	assert.False(t, acked)
+	assert.True(t, nacked)
+	assert.Len(t, ch, 0)
+}
+
+func TestComponent_processBatch_RecoversPanic(t *testing.T) {
+	bp := func([]Message) error {
+		panic("BATCH PANIC")
+	}
+	c, err := NewBatch("test", bp, &mockConsumerFactory{}, FailureStrategy(NackStrategy))
+	assert.NoError(t, err)
+	m := &recordingMessage{mockMessage: mockMessage{ctx: context.Background()}}
+	c.processBatch(context.Background(), []Message{m}, make(chan error, 1))
+	_, nacked := m.state()
+	assert.True(t, nacked)
+}
+
+func TestComponent_processBatch_MessageAckRetry(t *testing.T) {
+	var sizes []int
+	bp := func(mm []Message) error {
+		sizes = append(sizes, len(mm))
+		if len(sizes) == 1 {
+			assert.NoError(t, mm[0].Ack())
+			return errors.New("PROC ERROR")
+		}
+		for _, m := range mm {
+			assert.NoError(t, m.Ack())
+		}
+		return nil
+	}
+	c, err := NewBatch("test", bp, &mockConsumerFactory{}, BatchAckMode(MessageAck), ProcessorRetry(2, retry.Backoff{Initial: time.Millisecond, Max: time.Millisecond}))
+	assert.NoError(t, err)
+	mm := []*recordingMessage{{mockMessage: mockMessage{ctx: context.Background()}}, {mockMessage: mockMessage{ctx: context.Background()}}}
+	ch := make(chan error, 1)
+	c.processBatch(context.Background(), []Message{mm[0], mm[1]}, ch)
+	assert.Equal(t, []int{2, 1}, sizes)
+	for _, m := range mm {
+		acked, nacked := m.state()
+		assert.True(t, acked)
+		assert.False(t, nacked)
+		assert.Equal(t, 1, m.calls)
+	}
+	assert.Len(t, ch, 0)
+}
+
+func TestComponent_processBatch_MessageAckRetryCanceled(t *testing.T) {
+	ctx, cnl := context.WithCancel(context.Background())
+	bp := func(mm []Message) error {
+		assert.NoError(t, mm[0].Ack())
+		cnl()
+		return errors.New("PROC ERROR")
+	}
+	c, err := NewBatch("test", bp, &mockConsumerFactory{}, BatchAckMode(MessageAck), ProcessorRetry(2, retry.Backoff{Initial: time.Minute, Max: time.Minute}))
+	assert.NoError(t, err)
+	mm := []*recordingMessage{{mockMessage: mockMessage{ctx: context.Background()}}, {mockMessage: mockMessage{ctx: context.Background()}}}
+	c.processBatch(ctx, []Message{mm[0], mm[1]}, make(chan error, 1))
+	acked, nacked := mm[0].state()
+	assert.True(t, acked)
+	assert.False(t, nacked)
+	assert.Equal(t, 1, mm[0].calls)
+	acked, nacked = mm[1].state()
+	assert.False(t, acked)
+	assert.True(t, nacked)
+}
+
+func TestComponent_processBatch_RetryTier(t *testing.T) {
+	processed := 0
+	bp := func(mm []Message) error {
+		processed += len(mm)
+		return nil
+	}
+	c, err := NewBatch("test", bp, &mockConsumerFactory{})
+	assert.NoError(t, err)
+	retryAt := map[string]string{RetryAtHeader: time.Now().Add(2 * time.Second).UTC().Format(time.RFC3339)}
+	ctx, cnl := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cnl()
+	m := &recordingMessage{mockMessage: mockMessage{ctx: context.Background(), headers: retryAt}}
+	c.processBatch(ctx, []Message{&mockMessage{ctx: context.Background()}, m}, make(chan error, 1))
+	assert.Equal(t, 0, processed)
+	acked, _ := m.state()
+	assert.False(t, acked)
+	start := time.Now()
+	c.processBatch(context.Background(), []Message{m}, make(chan error, 1))
+	assert.True(t, time.Since(start) > 500*time.Millisecond)
+	assert.Equal(t, 1, processed)
+	acked, _ = m.state()
+	assert.True(t, acked)
+}
+
+func TestRun_Batch(t *testing.T) {
+	cnr := mockConsumer{
+		chMsg: make(chan Message, 10),
+		chErr: make(chan error, 10),
+	}
+	var mu sync.Mutex
+	var sizes []int
+	bp := func(mm []Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sizes = append(sizes, len(mm))
+		return nil
+	}
+	cmp, err := NewBatch("test", bp, &mockConsumerFactory{c: &cnr}, BatchSize(2), BatchLinger(20*time.Millisecond), Workers(1))
+	assert.NoError(t, err)
+	mm := make([]*recordingMessage, 5)
+	for i := range mm {
+		mm[i] = &recordingMessage{mockMessage: mockMessage{ctx: context.Background()}}
+		cnr.chMsg <- mm[i]
+	}
+	ch := make(chan bool)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		assert.NoError(t, cmp.Run(ctx))
+		ch <- true
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cnl()
+	assert.True(t, <-ch)
+	mu.Lock()
+	assert.Equal(t, []int{2, 2, 1}, sizes)
+	mu.Unlock()
+	for _, m := range mm {
+		acked, _ := m.state()
+		assert.True(t, acked)
+	}
+}
+
+func TestBatcher(t *testing.T) {
+	b := newBatcher(2, 10*time.Millisecond)
+	assert.Nil(t, b.expired())
+	assert.False(t, b.add(&mockMessage{}))
+	assert.NotNil(t, b.expired())
+	assert.True(t, b.add(&mockMessage{}))
+	assert.Len(t, b.flush(), 2)
+	assert.Nil(t, b.expired())
+	assert.False(t, b.add(&mockMessage{}))
+	<-b.expired()
+	assert.Len(t, b.flush(), 1)
+}
+
+func TestAckMode_String(t *testing.T) {
+	assert.Equal(t, "BatchAck", BatchAck.String())
+	assert.Equal(t, "MessageAck", MessageAck.String())
+	assert.Equal(t, "N/A", AckMode(5).String())
+}
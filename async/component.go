@@ -2,14 +2,24 @@ package async
 
 import (
 	"context"
+	"runtime"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/health"
 	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/reliability/retry"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var consumerErrors *prometheus.CounterVec
+var (
+	consumerErrors  *prometheus.CounterVec
+	processorPanics *prometheus.CounterVec
+)
 
 func init() {
 	consumerErrors = prometheus.NewCounterVec(
@@ -21,34 +31,68 @@ func init() {
 		},
 		[]string{"name"},
 	)
-	prometheus.MustRegister(consumerErrors)
+	processorPanics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "async",
+			Name:      "processor_panics",
+			Help:      "Processor panics, classified by name",
+		},
+		[]string{"name"},
+	)
+	prometheus.MustRegister(consumerErrors, processorPanics)
 }
 
 func consumerErrorsInc(name string) {
 	consumerErrors.WithLabelValues(name).Inc()
 }
 
+func processorPanicsInc(name string) {
+	processorPanics.WithLabelValues(name).Inc()
+}
+
 // Component implementation of a async component.
 type Component struct {
 	name         string
 	proc         ProcessorFunc
+	mw           []MiddlewareFunc
+	dedup        MiddlewareFunc
 	failStrategy FailStrategy
 	cf           ConsumerFactory
 	retries      int
 	retryWait    time.Duration
+	backoff      *retry.Backoff
+	backoffReset time.Duration
+	procRetries  int
+	procBackoff  retry.Backoff
+	retryable    RetryableFunc
 	info         map[string]interface{}
+	workers      int
+	maxInFlight  int
+	key          KeyFunc
+	batchProc    BatchProcessorFunc
+	batchSize    int
+	batchLinger  time.Duration
+	batchAck     AckMode
+	dl           *deadLetter
+	mu           sync.Mutex
+	cns          Consumer
 }
 
 // New returns a new async component. The default behavior is to return a error of failure.
 // Use options to change the default behavior.
+// The component registers a readiness check named after it, which succeeds while the component consumes messages.
 func New(name string, p ProcessorFunc, cf ConsumerFactory, oo ...OptionFunc) (*Component, error) {
-	if name == "" {
-		return nil, errors.New("name is required")
-	}
-
 	if p == nil {
 		return nil, errors.New("work processor is required")
 	}
+	return newComponent(name, cf, func(c *Component) { c.proc = p }, oo...)
+}
+
+func newComponent(name string, cf ConsumerFactory, setProc func(*Component), oo ...OptionFunc) (*Component, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
 
 	if cf == nil {
 		return nil, errors.New("consumer is required")
@@ -56,13 +100,16 @@ func New(name string, p ProcessorFunc, cf ConsumerFactory, oo ...OptionFunc) (*C
 
 	c := &Component{
 		name:         name,
-		proc:         p,
 		cf:           cf,
 		failStrategy: NackExitStrategy,
 		retries:      0,
 		retryWait:    0,
+		batchSize:    defaultBatchSize,
+		batchLinger:  defaultBatchLinger,
+		retryable:    retryable,
 		info:         make(map[string]interface{}),
 	}
+	setProc(c)
 
 	for _, o := range oo {
 		err := o(c)
@@ -71,6 +118,29 @@ func New(name string, p ProcessorFunc, cf ConsumerFactory, oo ...OptionFunc) (*C
 		}
 	}
 
+	if c.key != nil && c.batchProc != nil {
+		return nil, errors.New("ordered processing is not supported with batch processing")
+	}
+
+	if c.batchProc != nil && c.maxInFlight > 0 && c.maxInFlight < c.batchSize {
+		return nil, errors.New("max in-flight has to be at least the batch size")
+	}
+
+	mw := c.mw
+	if c.dedup != nil {
+		mw = append([]MiddlewareFunc{c.dedup}, mw...)
+	}
+	if len(mw) > 0 {
+		if c.batchProc != nil {
+			return nil, errors.New("middlewares and idempotency are not supported with batch processing")
+		}
+		c.proc = chain(c.proc, mw...)
+	}
+
+	if c.key != nil && c.workers == 0 {
+		c.workers = runtime.NumCPU()
+	}
+
 	c.setupInfo()
 	return c, nil
 }
@@ -80,30 +150,55 @@ func (c *Component) Info() map[string]interface{} {
 	return c.info
 }
 
-// Run starts the consumer processing loop messages.
+// Run starts the consumer processing loop messages. The readiness check of the component is registered while it runs,
+// which fails when another component with the same name is running.
 func (c *Component) Run(ctx context.Context) error {
+	err := health.RegisterUnique(c.checkName(), health.Readiness, c.check)
+	if err != nil {
+		return errors.Wrap(err, "failed to register readiness check")
+	}
+	defer health.Deregister(c.checkName())
 
-	var err error
-
-	for i := 0; i <= c.retries; i++ {
-		err = c.processing(ctx)
+	b := retry.Backoff{Initial: c.retryWait, Multiplier: 1}
+	if c.backoff != nil {
+		b = *c.backoff
+	}
+	retries := 0
+	for {
+		start := time.Now()
+		err := c.processing(ctx)
 		if err == nil {
 			return nil
 		}
-		if ctx.Err() == context.Canceled {
-			break
+		if ctx.Err() != nil {
+			return err
 		}
 		consumerErrorsInc(c.name)
-		if c.retries > 0 {
-			log.Errorf("failed run, retry %d/%d with %v wait: %v", i, c.retries, c.retryWait, err)
-			time.Sleep(c.retryWait)
+		if c.backoffReset > 0 && time.Since(start) >= c.backoffReset {
+			retries = 0
+		}
+		if c.retries != retry.Infinite && retries >= c.retries {
+			return err
+		}
+		wait := b.Wait(retries)
+		retries++
+		log.Errorf("failed run, retry %d/%s with %v wait: %v", retries, c.retriesString(), wait, err)
+		if retry.Sleep(ctx, wait) != nil {
+			return err
 		}
 	}
+}
 
-	return err
+func (c *Component) retriesString() string {
+	if c.retries == retry.Infinite {
+		return "infinite"
+	}
+	return strconv.Itoa(c.retries)
 }
 
 func (c *Component) processing(ctx context.Context) error {
+	ctx, cnl := context.WithCancel(ctx)
+	defer cnl()
 
 	cns, err := c.cf.Create()
 	if err != nil {
@@ -116,41 +211,127 @@ func (c *Component) processing(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to get consumer channels")
 	}
+	c.setConsumer(cns)
+	defer c.setConsumer(nil)
 
-	failCh := make(chan error)
+	failCh := make(chan error, 1)
+	p := newPool(ctx, c.name, c.workers, c.maxInFlight, c.key, func(mm []Message) {
+		mm, done := c.track(mm)
+		defer done()
+		if c.batchProc != nil {
+			c.processBatch(ctx, mm, failCh)
+			return
+		}
+		c.processMessage(ctx, mm[0], failCh)
+	})
 
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
+		defer p.close()
+		b := newBatcher(c.batchSize, c.batchLinger)
+		defer b.stop()
 		for {
 			select {
 			case <-ctx.Done():
 				log.Info("closing consumer")
-				failCh <- cns.Close()
+				p.close()
+				fail(failCh, cns.Close())
+				return
 			case msg := <-chMsg:
 				log.Debug("New message from consumer arrived")
-				go c.processMessage(msg, failCh)
+				if c.batchProc == nil {
+					c.submit(ctx, p, []Message{msg})
+					continue
+				}
+				if b.add(msg) {
+					c.submit(ctx, p, b.flush())
+				}
+			case <-b.expired():
+				c.submit(ctx, p, b.flush())
 			case errMsg := <-chErr:
-				failCh <- errors.Wrap(errMsg, "an error occurred during message consumption")
+				fail(failCh, errors.Wrap(errMsg, "an error occurred during message consumption"))
 				return
 			}
 		}
 	}()
-	return <-failCh
+	err = <-failCh
+	// the in-flight messages are completed before the consumer is closed and possibly recreated
+	cnl()
+	<-done
+	return err
 }
 
-func (c *Component) processMessage(msg Message, ch chan error) {
-	err := c.proc(msg)
+func (c *Component) submit(ctx context.Context, p *pool, mm []Message) {
+	err := p.submit(ctx, mm)
+	if err != nil {
+		log.Debugf("%d messages dropped: %v", len(mm), err)
+	}
+}
+
+func (c *Component) processMessage(ctx context.Context, msg Message, ch chan error) {
+	if !awaitRetry(ctx, msg) {
+		return
+	}
+	err := c.processWithRetry(ctx, []Message{msg}, func() error {
+		return c.observe(func() error {
+			return c.process(msg)
+		})
+	})
+	if err == errRetryCanceled {
+		return
+	}
 	if err != nil {
+		c.failed(1)
 		err := c.executeFailureStrategy(msg, err)
 		if err != nil {
-			ch <- err
+			fail(ch, err)
 		}
 		return
 	}
 	if err := msg.Ack(); err != nil {
-		ch <- err
+		fail(ch, err)
+	}
+}
+
+// fail reports the error unless a error has already been reported.
+func fail(ch chan<- error, err error) {
+	select {
+	case ch <- err:
+	default:
 	}
 }
 
+// process runs the processor converting a panic into a error, which is handled by the failure strategy.
+func (c *Component) process(msg Message) error {
+	return c.recoverPanic([]Message{msg}, func() error {
+		return c.proc(msg)
+	})
+}
+
+// recoverPanic runs the function converting a panic into a error. The spans of the messages are marked
+// as errored and the panic is logged along with its stack trace.
+func (c *Component) recoverPanic(mm []Message, f func() error) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		p := errors.FromPanic(r)
+		processorPanicsInc(c.name)
+		for _, msg := range mm {
+			sp := opentracing.SpanFromContext(msg.Context())
+			if sp != nil {
+				ext.Error.Set(sp, true)
+				sp.LogKV("event", "error", "error.kind", "panic", "message", p.Error())
+			}
+		}
+		log.FromContext(mm[0].Context()).Errorf("processor of %s recovered from panic: %v\n%s", c.name, p.Value, p.Stack)
+		err = p
+	}()
+	return f()
+}
+
 func (c *Component) executeFailureStrategy(msg Message, err error) error {
 	log.Errorf("failed to process message, failure strategy executed: %v", err)
 	switch c.failStrategy {
@@ -166,15 +347,103 @@ func (c *Component) executeFailureStrategy(msg Message, err error) error {
 		if err != nil {
 			return errors.Wrap(err, "ack failed when executing failure strategy")
 		}
+	case DeadLetterStrategy:
+		dlErr := c.dl.publish(msg, err)
+		if dlErr != nil {
+			return errors.Aggregate(err, dlErr, errors.Wrap(msg.Nack(), "failed to NACK message"))
+		}
+		err := msg.Ack()
+		if err != nil {
+			return errors.Wrap(err, "ack failed when executing failure strategy")
+		}
 	default:
 		return errors.New("invalid failure strategy")
 	}
 	return nil
 }
 
+func (c *Component) checkName() string {
+	return "async-" + c.name
+}
+
+func (c *Component) setConsumer(cns Consumer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cns = cns
+}
+
+func (c *Component) consumer() Consumer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cns
+}
+
+// Health reports the component as down while it is not consuming, e.g. during a consumer retry wait,
+// or when its consumer reports itself as down.
+func (c *Component) Health() health.Status {
+	cns := c.consumer()
+	if cns == nil {
+		return health.Down
+	}
+	r, ok := cns.(health.Reporter)
+	if ok {
+		return r.Health()
+	}
+	return health.Up
+}
+
+// check succeeds while the component is healthy and, if supported, the consumer passes its check.
+func (c *Component) check(ctx context.Context) error {
+	cns := c.consumer()
+	if cns == nil {
+		return errors.Errorf("component %s is not consuming", c.name)
+	}
+	if c.Health() == health.Down {
+		return errors.Errorf("component %s is down", c.name)
+	}
+	hc, ok := cns.(HealthChecker)
+	if !ok {
+		return nil
+	}
+	return hc.Check(ctx)
+}
+
 func (c *Component) setupInfo() {
 	c.info["type"] = "async"
 	c.info["fail-strategy"] = c.failStrategy.String()
 	c.info["consumer-retries"] = c.retries
 	c.info["consumer-timeout"] = c.retryWait.String()
+	if c.backoff != nil {
+		c.info["consumer-backoff"] = map[string]interface{}{
+			"initial": c.backoff.Initial.String(),
+			"max":     c.backoff.Max.String(),
+			"reset":   c.backoffReset.String(),
+		}
+	}
+	if c.procRetries > 0 {
+		c.info["processor-retries"] = c.procRetries
+	}
+	if c.workers > 0 {
+		c.info["workers"] = c.workers
+	}
+	if c.maxInFlight > 0 {
+		c.info["max-in-flight"] = c.maxInFlight
+	}
+	if c.key != nil {
+		c.info["ordered"] = true
+	}
+	if len(c.mw) > 0 {
+		c.info["middlewares"] = len(c.mw)
+	}
+	if c.dedup != nil {
+		c.info["idempotent"] = true
+	}
+	if c.dl != nil {
+		c.info["dead-letter"] = c.dl.dest
+	}
+	if c.batchProc != nil {
+		c.info["batch-size"] = c.batchSize
+		c.info["batch-linger"] = c.batchLinger.String()
+		c.info["batch-ack"] = c.batchAck.String()
+	}
 }
@@ -2,11 +2,16 @@ package async
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/health"
+	"github.com/mantzas/patron/reliability/retry"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 )
 
 func TestNew(t *testing.T) {
@@ -157,11 +162,55 @@ func TestRun_ConsumeError(t *testing.T) {
 
 func TestRun_ConsumeError_WithRetry(t *testing.T) {
 	proc := mockProcessor{retError: true}
-	cmp, err := New("test", proc.Process, &mockConsumerFactory{retErr: true}, ConsumerRetry(3, 2*time.Millisecond))
+	cf := &mockConsumerFactory{retErr: true}
+	cmp, err := New("test", proc.Process, cf, ConsumerRetry(3, 2*time.Millisecond))
 	assert.NoError(t, err)
 	ctx := context.Background()
 	err = cmp.Run(ctx)
 	assert.Error(t, err)
+	assert.Equal(t, 4, cf.creates)
+}
+
+func TestRun_ConsumeError_WithBackoff(t *testing.T) {
+	proc := mockProcessor{}
+	cf := &mockConsumerFactory{retErr: true}
+	cmp, err := New("test", proc.Process, cf, ConsumerRetry(3, time.Minute),
+		ConsumerBackoff(retry.Backoff{Initial: time.Millisecond, Max: 2 * time.Millisecond, Jitter: 0.1}, 0))
+	assert.NoError(t, err)
+	err = cmp.Run(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 4, cf.creates)
+}
+
+func TestRun_ConsumeError_BackoffReset(t *testing.T) {
+	proc := mockProcessor{}
+	cf := &mockConsumerFactory{retErr: true}
+	cmp, err := New("test", proc.Process, cf, ConsumerRetry(2, 0),
+		ConsumerBackoff(retry.Backoff{Initial: time.Millisecond}, time.Nanosecond))
+	assert.NoError(t, err)
+	ctx, cnl := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cnl()
+	assert.Error(t, cmp.Run(ctx))
+	assert.True(t, cf.creates > 3)
+}
+
+func TestRun_ConsumeError_InfiniteRetry_Shutdown(t *testing.T) {
+	proc := mockProcessor{}
+	cmp, err := New("test", proc.Process, &mockConsumerFactory{retErr: true}, ConsumerRetry(retry.Infinite, time.Minute))
+	assert.NoError(t, err)
+	ctx, cnl := context.WithCancel(context.Background())
+	ch := make(chan error)
+	go func() {
+		ch <- cmp.Run(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cnl()
+	select {
+	case err := <-ch:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "shutdown delayed by consumer retry wait")
+	}
 }
 
 func TestRun_Process_Shutdown(t *testing.T) {
@@ -185,6 +234,139 @@ func TestRun_Process_Shutdown(t *testing.T) {
 	assert.True(t, <-ch)
 }
 
+func TestRun_Process_Workers_Shutdown(t *testing.T) {
+	cnr := mockConsumer{
+		chMsg: make(chan Message, 10),
+		chErr: make(chan error, 10),
+	}
+	proc := mockProcessor{retError: false}
+	cmp, err := New("test", proc.Process, &mockConsumerFactory{c: &cnr}, Workers(2), MaxInFlight(4))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cmp.Info()["workers"])
+	assert.Equal(t, 4, cmp.Info()["max-in-flight"])
+	for i := 0; i < 10; i++ {
+		cnr.chMsg <- &mockMessage{ctx: context.Background()}
+	}
+	ch := make(chan bool)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		err1 := cmp.Run(ctx)
+		assert.NoError(t, err1)
+		ch <- true
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cnl()
+	assert.True(t, <-ch)
+}
+
+func TestComponent_processing_DrainsBeforeClose(t *testing.T) {
+	cns := &mockConsumer{chMsg: make(chan Message, 1), chErr: make(chan error, 1)}
+	var processed int32
+	proc := func(msg Message) error {
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&cns.closed))
+		atomic.StoreInt32(&processed, 1)
+		return nil
+	}
+	cmp, err := New("drain", proc, &mockConsumerFactory{c: cns})
+	assert.NoError(t, err)
+	cns.chMsg <- &mockMessage{ctx: context.Background()}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cns.chErr <- errors.New("CONSUMER ERROR")
+	}()
+	assert.Error(t, cmp.processing(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&processed))
+	assert.NotEqual(t, int32(0), atomic.LoadInt32(&cns.closed))
+}
+
+func TestComponent_Health(t *testing.T) {
+	tests := []struct {
+		name     string
+		cns      Consumer
+		health   health.Status
+		checkErr bool
+	}{
+		{"consumer without health check", &mockConsumer{chMsg: make(chan Message), chErr: make(chan error)}, health.Up, false},
+		{"healthy consumer", &mockCheckedConsumer{mockConsumer: mockConsumer{chMsg: make(chan Message), chErr: make(chan error)}, status: health.Up}, health.Up, false},
+		{"failing check consumer", &mockCheckedConsumer{mockConsumer: mockConsumer{chMsg: make(chan Message), chErr: make(chan error)}, status: health.Up, err: errors.New("TEST")}, health.Up, true},
+		{"down consumer", &mockCheckedConsumer{mockConsumer: mockConsumer{chMsg: make(chan Message), chErr: make(chan error)}, status: health.Down}, health.Down, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc := mockProcessor{}
+			cmp, err := New("test", proc.Process, &mockConsumerFactory{c: tt.cns})
+			assert.NoError(t, err)
+			assert.False(t, readinessChecked("async-test"))
+			ch := make(chan bool)
+			ctx, cnl := context.WithCancel(context.Background())
+			go func() {
+				assert.NoError(t, cmp.Run(ctx))
+				ch <- true
+			}()
+			time.Sleep(10 * time.Millisecond)
+			assert.True(t, readinessChecked("async-test"))
+			assert.Equal(t, tt.health, cmp.Health())
+			if tt.checkErr {
+				assert.Error(t, cmp.check(ctx))
+			} else {
+				assert.NoError(t, cmp.check(ctx))
+			}
+			cnl()
+			assert.True(t, <-ch)
+			assert.Equal(t, health.Down, cmp.Health())
+			assert.Error(t, cmp.check(context.Background()))
+			assert.False(t, readinessChecked("async-test"))
+		})
+	}
+}
+
+func TestComponent_Run_DuplicateName(t *testing.T) {
+	proc := mockProcessor{}
+	cmp1, err := New("duplicate", proc.Process, &mockConsumerFactory{c: &mockConsumer{chMsg: make(chan Message), chErr: make(chan error)}})
+	assert.NoError(t, err)
+	cmp2, err := New("duplicate", proc.Process, &mockConsumerFactory{c: &mockConsumer{chMsg: make(chan Message), chErr: make(chan error)}})
+	assert.NoError(t, err)
+	ch := make(chan bool)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		assert.NoError(t, cmp1.Run(ctx))
+		ch <- true
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Error(t, cmp2.Run(ctx))
+	assert.True(t, readinessChecked("async-duplicate"))
+	cnl()
+	assert.True(t, <-ch)
+	assert.False(t, readinessChecked("async-duplicate"))
+}
+
+func readinessChecked(name string) bool {
+	for _, r := range health.Evaluate(context.Background(), health.Readiness).Checks {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestComponent_process_RecoversPanic(t *testing.T) {
+	mtr := mocktracer.New()
+	sp := mtr.StartSpan("test")
+	ctx := opentracing.ContextWithSpan(context.Background(), sp)
+	proc := func(msg Message) error {
+		panic("PROC PANIC")
+	}
+	cmp, err := New("test", proc, &mockConsumerFactory{})
+	assert.NoError(t, err)
+	err = cmp.process(&mockMessage{ctx: ctx})
+	p, ok := err.(*errors.Panic)
+	assert.True(t, ok)
+	assert.Equal(t, "PROC PANIC", p.Value)
+	assert.Equal(t, true, sp.(*mocktracer.MockSpan).Tag("error"))
+	assert.Error(t, cmp.process(&mockMessage{ctx: context.Background()}))
+}
+
 func TestInfo(t *testing.T) {
 	cnr := mockConsumer{
 		chMsg: make(chan Message, 10),
@@ -221,7 +403,9 @@ func TestInfo(t *testing.T) {
 }
 
 type mockMessage struct {
-	ctx context.Context
+	ctx     context.Context
+	body    []byte
+	headers map[string]string
 }
 
 func (mm *mockMessage) Context() context.Context {
@@ -240,6 +424,18 @@ func (mm *mockMessage) Nack() error {
 	return nil
 }
 
+func (mm *mockMessage) Body() []byte {
+	return mm.body
+}
+
+func (mm *mockMessage) Headers() map[string]string {
+	return mm.headers
+}
+
+func (mm *mockMessage) Metadata() interface{} {
+	return nil
+}
+
 type mockProcessor struct {
 	retError bool
 }
@@ -252,11 +448,13 @@ func (mp *mockProcessor) Process(msg Message) error {
 }
 
 type mockConsumerFactory struct {
-	c      Consumer
-	retErr bool
+	c       Consumer
+	retErr  bool
+	creates int
 }
 
 func (mcf *mockConsumerFactory) Create() (Consumer, error) {
+	mcf.creates++
 	if mcf.retErr {
 		return nil, errors.New("FACTORY ERROR")
 	}
@@ -267,6 +465,7 @@ type mockConsumer struct {
 	consumeError bool
 	chMsg        chan Message
 	chErr        chan error
+	closed       int32
 }
 
 func (mc *mockConsumer) SetTimeout(timeout time.Duration) {
@@ -280,9 +479,24 @@ func (mc *mockConsumer) Consume(context.Context) (<-chan Message, <-chan error,
 }
 
 func (mc *mockConsumer) Close() error {
+	atomic.AddInt32(&mc.closed, 1)
 	return nil
 }
 
 func (mc *mockConsumer) Info() map[string]interface{} {
 	return map[string]interface{}{"key": "value"}
 }
+
+type mockCheckedConsumer struct {
+	mockConsumer
+	status health.Status
+	err    error
+}
+
+func (mc *mockCheckedConsumer) Health() health.Status {
+	return mc.status
+}
+
+func (mc *mockCheckedConsumer) Check(ctx context.Context) error {
+	return mc.err
+}
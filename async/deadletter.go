@@ -0,0 +1,112 @@
+package async
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+)
+
+const (
+	// AttemptHeader holds the number of times a dead lettered message has failed.
+	AttemptHeader = "X-Attempt"
+	// ReasonHeader holds the error of the last failure of a dead lettered message.
+	ReasonHeader = "X-Dead-Letter-Reason"
+	// RetryAtHeader holds the time, in RFC3339 format, before which a message published to a retry tier
+	// is not processed.
+	RetryAtHeader = "X-Retry-At"
+)
+
+// DeadLetterMessage of a failed message, which holds its original payload, headers and metadata, e.g. the key
+// of a Kafka message, along with the reason of the failure and the attempt count, which are also set in the headers.
+type DeadLetterMessage struct {
+	Body     []byte
+	Headers  map[string]string
+	Metadata interface{}
+	Reason   string
+	Attempt  int
+}
+
+// DeadLetterPublisher interface for publishing dead letters to a destination, e.g. a topic or exchange.
+type DeadLetterPublisher interface {
+	Publish(ctx context.Context, dest string, dl *DeadLetterMessage) error
+}
+
+// RetryTier defines a destination to which failed messages are published for a delayed retry.
+type RetryTier struct {
+	Destination string
+	Delay       time.Duration
+}
+
+type deadLetter struct {
+	pub   DeadLetterPublisher
+	dest  string
+	tiers []RetryTier
+}
+
+// publish publishes the failed message to the retry tier of its attempt, or to the dead letter destination
+// when all retry tiers have been exhausted.
+func (dl *deadLetter) publish(msg Message, reason error) error {
+	hh := make(map[string]string, len(msg.Headers())+3)
+	for k, v := range msg.Headers() {
+		hh[k] = v
+	}
+	attempt, err := attempts(hh)
+	if err != nil {
+		return err
+	}
+
+	dest := dl.dest
+	delete(hh, RetryAtHeader)
+	if attempt < len(dl.tiers) {
+		dest = dl.tiers[attempt].Destination
+		hh[RetryAtHeader] = time.Now().Add(dl.tiers[attempt].Delay).UTC().Format(time.RFC3339)
+	}
+	attempt++
+	hh[AttemptHeader] = strconv.Itoa(attempt)
+	hh[ReasonHeader] = reason.Error()
+
+	log.FromContext(msg.Context()).Infof("publishing failed message, attempt %d, to %s", attempt, dest)
+	err = dl.pub.Publish(msg.Context(), dest, &DeadLetterMessage{Body: msg.Body(), Headers: hh, Metadata: msg.Metadata(), Reason: reason.Error(), Attempt: attempt})
+	return errors.Wrapf(err, "failed to publish message to %s", dest)
+}
+
+func attempts(hh map[string]string) (int, error) {
+	v, ok := hh[AttemptHeader]
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s header", AttemptHeader)
+	}
+	return n, nil
+}
+
+// awaitRetry waits, for messages published to a retry tier, until the retry time has been reached,
+// blocking the processing of the message. It returns false if the context is done while waiting.
+func awaitRetry(ctx context.Context, msg Message) bool {
+	v, ok := msg.Headers()[RetryAtHeader]
+	if !ok {
+		return true
+	}
+	at, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		log.FromContext(msg.Context()).Warnf("ignoring invalid %s header %s: %v", RetryAtHeader, v, err)
+		return true
+	}
+	wait := time.Until(at)
+	if wait <= 0 {
+		return true
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
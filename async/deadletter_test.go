@@ -0,0 +1,91 @@
+package async
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPublisher struct {
+	retError bool
+	dest     string
+	dl       *DeadLetterMessage
+}
+
+func (mp *mockPublisher) Publish(_ context.Context, dest string, dl *DeadLetterMessage) error {
+	if mp.retError {
+		return errors.New("PUBLISH ERROR")
+	}
+	mp.dest = dest
+	mp.dl = dl
+	return nil
+}
+
+func TestComponent_processMessage_DeadLetter(t *testing.T) {
+	tiers := []RetryTier{{Destination: "retry-5s", Delay: 5 * time.Second}, {Destination: "retry-1m", Delay: time.Minute}}
+	tests := []struct {
+		name        string
+		attempt     string
+		pubErr      bool
+		wantDest    string
+		wantAttempt int
+		wantRetryAt bool
+		wantAcked   bool
+		wantNacked  bool
+		wantErr     bool
+	}{
+		{name: "first retry tier", wantDest: "retry-5s", wantAttempt: 1, wantRetryAt: true, wantAcked: true},
+		{name: "second retry tier", attempt: "1", wantDest: "retry-1m", wantAttempt: 2, wantRetryAt: true, wantAcked: true},
+		{name: "dead letter", attempt: "2", wantDest: "dlq", wantAttempt: 3, wantAcked: true},
+		{name: "invalid attempt", attempt: "a", wantNacked: true, wantErr: true},
+		{name: "publish failure", pubErr: true, wantNacked: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &mockPublisher{retError: tt.pubErr}
+			proc := mockProcessor{retError: true}
+			c, err := New("test", proc.Process, &mockConsumerFactory{}, DeadLetter(pub, "dlq", tiers...))
+			assert.NoError(t, err)
+			hh := map[string]string{"key": "value"}
+			if tt.attempt != "" {
+				hh[AttemptHeader] = tt.attempt
+				hh[RetryAtHeader] = time.Now().Add(-time.Second).UTC().Format(time.RFC3339)
+			}
+			msg := &recordingMessage{mockMessage: mockMessage{ctx: context.Background(), body: []byte("body"), headers: hh}}
+			ch := make(chan error, 1)
+			c.processMessage(context.Background(), msg, ch)
+			acked, nacked := msg.state()
+			assert.Equal(t, tt.wantAcked, acked)
+			assert.Equal(t, tt.wantNacked, nacked)
+			if tt.wantErr {
+				assert.Error(t, <-ch)
+				return
+			}
+			assert.Len(t, ch, 0)
+			assert.Equal(t, tt.wantDest, pub.dest)
+			assert.Equal(t, []byte("body"), pub.dl.Body)
+			assert.Equal(t, tt.wantAttempt, pub.dl.Attempt)
+			assert.Equal(t, "value", pub.dl.Headers["key"])
+			assert.Equal(t, strconv.Itoa(tt.wantAttempt), pub.dl.Headers[AttemptHeader])
+			assert.Equal(t, "PROC ERROR", pub.dl.Headers[ReasonHeader])
+			_, ok := pub.dl.Headers[RetryAtHeader]
+			assert.Equal(t, tt.wantRetryAt, ok)
+		})
+	}
+}
+
+func TestAwaitRetry(t *testing.T) {
+	msg := func(retryAt string) Message {
+		return &mockMessage{headers: map[string]string{RetryAtHeader: retryAt}}
+	}
+	canceled, cnl := context.WithCancel(context.Background())
+	cnl()
+	assert.True(t, awaitRetry(context.Background(), &mockMessage{}))
+	assert.True(t, awaitRetry(context.Background(), msg(time.Now().Add(-time.Minute).Format(time.RFC3339))))
+	assert.True(t, awaitRetry(context.Background(), &mockMessage{ctx: context.Background(), headers: map[string]string{RetryAtHeader: "invalid"}}))
+	assert.False(t, awaitRetry(canceled, msg(time.Now().Add(time.Minute).Format(time.RFC3339))))
+}
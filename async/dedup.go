@@ -0,0 +1,137 @@
+package async
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var duplicates *prometheus.CounterVec
+
+func init() {
+	duplicates = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "async",
+			Name:      "duplicates",
+			Help:      "Duplicate messages which have been skipped, classified by name",
+		},
+		[]string{"name"},
+	)
+	prometheus.MustRegister(duplicates)
+}
+
+// IDFunc definition of a function which extracts the ID of a message, which is used for deduplication.
+type IDFunc func(Message) string
+
+// HeaderID returns a ID func which extracts the ID of a message from a header.
+func HeaderID(key string) IDFunc {
+	return func(msg Message) string {
+		return msg.Headers()[key]
+	}
+}
+
+// DedupStore interface for keeping track of the IDs of processed messages, e.g. in memory, Redis or SQL.
+type DedupStore interface {
+	Seen(ctx context.Context, id string) (bool, error)
+	Mark(ctx context.Context, id string) error
+}
+
+// MemoryStore is a in-memory dedup store, which keeps the most recently processed IDs up to a size
+// and for a TTL.
+type MemoryStore struct {
+	sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memoryEntry struct {
+	id string
+	at time.Time
+}
+
+// NewMemoryStore creates a in-memory dedup store.
+func NewMemoryStore(size int, ttl time.Duration) (*MemoryStore, error) {
+	if size <= 0 {
+		return nil, errors.New("size must be positive")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+	return &MemoryStore{size: size, ttl: ttl, ll: list.New(), items: make(map[string]*list.Element)}, nil
+}
+
+// Seen returns whether the ID has been marked within the TTL.
+func (ms *MemoryStore) Seen(_ context.Context, id string) (bool, error) {
+	ms.Lock()
+	defer ms.Unlock()
+	el, ok := ms.items[id]
+	if !ok {
+		return false, nil
+	}
+	if time.Since(el.Value.(*memoryEntry).at) >= ms.ttl {
+		ms.remove(el)
+		return false, nil
+	}
+	ms.ll.MoveToFront(el)
+	return true, nil
+}
+
+// Mark marks the ID as processed, evicting the least recently used ID when the size is exceeded.
+func (ms *MemoryStore) Mark(_ context.Context, id string) error {
+	ms.Lock()
+	defer ms.Unlock()
+	if el, ok := ms.items[id]; ok {
+		el.Value.(*memoryEntry).at = time.Now()
+		ms.ll.MoveToFront(el)
+		return nil
+	}
+	ms.items[id] = ms.ll.PushFront(&memoryEntry{id: id, at: time.Now()})
+	if ms.ll.Len() > ms.size {
+		ms.remove(ms.ll.Back())
+	}
+	return nil
+}
+
+func (ms *MemoryStore) remove(el *list.Element) {
+	ms.ll.Remove(el)
+	delete(ms.items, el.Value.(*memoryEntry).id)
+}
+
+// dedup skips the messages which have already been processed, as reported by the store, and marks
+// the messages which are processed successfully. Messages without a ID are always processed.
+func dedup(id IDFunc, store DedupStore, skipped func()) MiddlewareFunc {
+	return func(next ProcessorFunc) ProcessorFunc {
+		return func(msg Message) error {
+			k := id(msg)
+			if k == "" {
+				return next(msg)
+			}
+			ok, err := store.Seen(msg.Context(), k)
+			if err != nil {
+				return errors.Wrapf(err, "failed to check for duplicate message %s", k)
+			}
+			if ok {
+				log.FromContext(msg.Context()).Debugf("duplicate message %s skipped", k)
+				skipped()
+				return nil
+			}
+			err = next(msg)
+			if err != nil {
+				return err
+			}
+			err = store.Mark(msg.Context(), k)
+			if err != nil {
+				log.FromContext(msg.Context()).Errorf("failed to mark message %s as processed: %v", k, err)
+			}
+			return nil
+		}
+	}
+}
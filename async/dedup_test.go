@@ -0,0 +1,117 @@
+package async
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMemoryStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		ttl     time.Duration
+		wantErr bool
+	}{
+		{name: "success", size: 10, ttl: time.Minute, wantErr: false},
+		{name: "invalid size", size: 0, ttl: time.Minute, wantErr: true},
+		{name: "invalid ttl", size: 10, ttl: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMemoryStore(tt.size, tt.ttl)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	ms, err := NewMemoryStore(2, 20*time.Millisecond)
+	assert.NoError(t, err)
+	seen := func(id string) bool {
+		ok, err := ms.Seen(ctx, id)
+		assert.NoError(t, err)
+		return ok
+	}
+	assert.False(t, seen("1"))
+	assert.NoError(t, ms.Mark(ctx, "1"))
+	assert.NoError(t, ms.Mark(ctx, "2"))
+	assert.True(t, seen("1"))
+	assert.NoError(t, ms.Mark(ctx, "3"))
+	assert.True(t, seen("1"))
+	assert.False(t, seen("2"), "least recently used evicted")
+	assert.True(t, seen("3"))
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, seen("1"), "expired")
+	assert.NoError(t, ms.Mark(ctx, "3"))
+	assert.True(t, seen("3"))
+}
+
+type mockStore struct {
+	seenErr bool
+	markErr bool
+	ids     map[string]bool
+}
+
+func (ms *mockStore) Seen(_ context.Context, id string) (bool, error) {
+	if ms.seenErr {
+		return false, errors.New("SEEN ERROR")
+	}
+	return ms.ids[id], nil
+}
+
+func (ms *mockStore) Mark(_ context.Context, id string) error {
+	if ms.markErr {
+		return errors.New("MARK ERROR")
+	}
+	ms.ids[id] = true
+	return nil
+}
+
+func TestComponent_Idempotent(t *testing.T) {
+	tests := []struct {
+		name           string
+		store          *mockStore
+		wantCalls      int
+		wantErr        bool
+		wantDuplicates float64
+	}{
+		{name: "duplicate skipped", store: &mockStore{ids: map[string]bool{}}, wantCalls: 1, wantDuplicates: 1},
+		{name: "mark failure", store: &mockStore{ids: map[string]bool{}, markErr: true}, wantCalls: 2, wantDuplicates: 0},
+		{name: "seen failure", store: &mockStore{ids: map[string]bool{}, seenErr: true}, wantCalls: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			proc := func(Message) error {
+				calls++
+				return nil
+			}
+			before := metricValue(t, duplicates.WithLabelValues("idempotent-"+tt.name))
+			c, err := New("idempotent-"+tt.name, proc, &mockConsumerFactory{}, Idempotent(HeaderID("id"), tt.store))
+			assert.NoError(t, err)
+			assert.Equal(t, true, c.Info()["idempotent"])
+			msg := &mockMessage{ctx: context.Background(), headers: map[string]string{"id": "1"}}
+			for i := 0; i < 2; i++ {
+				err = c.proc(msg)
+				if tt.wantErr {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantDuplicates, metricValue(t, duplicates.WithLabelValues("idempotent-"+tt.name))-before)
+		})
+	}
+}
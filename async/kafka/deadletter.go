@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/mantzas/patron/async"
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/trace/kafka"
+)
+
+// DeadLetterPublisher publishes dead letters to a topic with a Kafka producer.
+type DeadLetterPublisher struct {
+	prod kafka.Producer
+}
+
+// NewDeadLetterPublisher creates a dead letter publisher, which uses the destination as the topic.
+// The producer has to return the result of sending, e.g. the sync producer, since a failed message is acknowledged
+// once its dead letter has been published, so the async producer is not supported.
+func NewDeadLetterPublisher(p kafka.Producer) (*DeadLetterPublisher, error) {
+	if p == nil {
+		return nil, errors.New("producer is required")
+	}
+	if _, ok := p.(*kafka.AsyncProducer); ok {
+		return nil, errors.New("async producer is not supported")
+	}
+	return &DeadLetterPublisher{prod: p}, nil
+}
+
+// Publish sends the dead letter, along with its headers and the key of the failed message, to the destination topic,
+// so that the retry tiers keep the partitioning of the messages.
+func (dp *DeadLetterPublisher) Publish(ctx context.Context, dest string, dl *async.DeadLetterMessage) error {
+	var key []byte
+	if md, ok := dl.Metadata.(Metadata); ok {
+		key = md.Key
+	}
+	return dp.prod.Send(ctx, kafka.NewMessageWithKey(dest, key, dl.Body, dl.Headers))
+}
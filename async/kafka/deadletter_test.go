@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mantzas/patron/async"
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/trace/kafka"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProducer struct {
+	retError bool
+	msg      *kafka.Message
+}
+
+func (mp *mockProducer) Send(_ context.Context, msg *kafka.Message) error {
+	if mp.retError {
+		return errors.New("SEND ERROR")
+	}
+	mp.msg = msg
+	return nil
+}
+
+func (mp *mockProducer) Error() <-chan error {
+	return nil
+}
+
+func (mp *mockProducer) Close() error {
+	return nil
+}
+
+func TestNewDeadLetterPublisher(t *testing.T) {
+	p, err := NewDeadLetterPublisher(nil)
+	assert.Error(t, err)
+	assert.Nil(t, p)
+	p, err = NewDeadLetterPublisher(&kafka.AsyncProducer{})
+	assert.Error(t, err)
+	assert.Nil(t, p)
+	p, err = NewDeadLetterPublisher(&mockProducer{})
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+}
+
+func TestDeadLetterPublisher_Publish(t *testing.T) {
+	tests := []struct {
+		name    string
+		prod    *mockProducer
+		md      interface{}
+		wantKey []byte
+		wantErr bool
+	}{
+		{name: "success", prod: &mockProducer{}, wantErr: false},
+		{name: "success with key", prod: &mockProducer{}, md: Metadata{Key: []byte("key")}, wantKey: []byte("key"), wantErr: false},
+		{name: "failure", prod: &mockProducer{retError: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewDeadLetterPublisher(tt.prod)
+			assert.NoError(t, err)
+			err = p.Publish(context.Background(), "dlq", &async.DeadLetterMessage{Body: []byte("body"), Headers: map[string]string{"key": "value"}, Metadata: tt.md})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, kafka.NewMessageWithKey("dlq", tt.wantKey, []byte("body"), map[string]string{"key": "value"}), tt.prod.msg)
+			}
+		})
+	}
+}
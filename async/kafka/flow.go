@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var partitionPaused *prometheus.GaugeVec
+
+func init() {
+	partitionPaused = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "kafka_consumer",
+			Name:      "partition_paused",
+			Help:      "Paused partitions, which are not consumed until their in-flight messages are drained, classified by topic and partition",
+		},
+		[]string{"topic", "partition"},
+	)
+	prometheus.MustRegister(partitionPaused)
+}
+
+// flow controls the consumption of a partition, which is paused when its in-flight messages, delivered but
+// not yet acknowledged, reach the max and resumed when they have been drained to half of it.
+type flow struct {
+	sync.Mutex
+	max      int
+	inFlight int
+	resumed  chan struct{}
+	stopped  bool
+	gauge    prometheus.Gauge
+}
+
+func newFlow(max int, topic string, partition int32) *flow {
+	g := partitionPaused.WithLabelValues(topic, strconv.FormatInt(int64(partition), 10))
+	g.Set(0)
+	return &flow{max: max, gauge: g}
+}
+
+// paused returns a channel which is closed when the partition is resumed, or nil when it is not paused.
+func (f *flow) paused() <-chan struct{} {
+	f.Lock()
+	defer f.Unlock()
+	return f.resumed
+}
+
+// deliver records a delivered message and pauses the partition when the max is reached.
+func (f *flow) deliver() {
+	f.Lock()
+	defer f.Unlock()
+	f.inFlight++
+	if f.resumed == nil && f.inFlight >= f.max {
+		f.resumed = make(chan struct{})
+		f.setPaused(1)
+	}
+}
+
+// release records a acknowledged message and resumes the partition when the in-flight messages are drained.
+func (f *flow) release() {
+	f.Lock()
+	defer f.Unlock()
+	f.inFlight--
+	if f.resumed != nil && f.inFlight <= f.max/2 {
+		close(f.resumed)
+		f.resumed = nil
+		f.setPaused(0)
+	}
+}
+
+// stop resets the metric when the partition is no longer consumed, e.g. after a rebalance.
+func (f *flow) stop() {
+	f.Lock()
+	defer f.Unlock()
+	f.setPaused(0)
+	f.stopped = true
+}
+
+func (f *flow) setPaused(v float64) {
+	if !f.stopped {
+		f.gauge.Set(v)
+	}
+}
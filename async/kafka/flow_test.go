@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/mantzas/patron/async"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func pausedValue(t *testing.T, g prometheus.Gauge) float64 {
+	m := dto.Metric{}
+	assert.NoError(t, g.Write(&m))
+	return m.Gauge.GetValue()
+}
+
+func TestFlow(t *testing.T) {
+	f := newFlow(4, "flow", 1)
+	g := partitionPaused.WithLabelValues("flow", "1")
+	for i := 0; i < 3; i++ {
+		f.deliver()
+	}
+	assert.Nil(t, f.paused())
+	f.deliver()
+	resumed := f.paused()
+	assert.NotNil(t, resumed)
+	assert.Equal(t, 1.0, pausedValue(t, g))
+	f.release()
+	assert.NotNil(t, f.paused())
+	f.release()
+	assert.Nil(t, f.paused())
+	assert.Equal(t, 0.0, pausedValue(t, g))
+	select {
+	case <-resumed:
+	default:
+		assert.Fail(t, "partition not resumed")
+	}
+	f.deliver()
+	f.deliver()
+	assert.NotNil(t, f.paused())
+	f.stop()
+	assert.Equal(t, 0.0, pausedValue(t, g))
+}
+
+func TestConsumer_consumePartition_Paused(t *testing.T) {
+	pc := &mockPartitionConsumer{msgs: make(chan *sarama.ConsumerMessage, 3)}
+	for i := 0; i < 3; i++ {
+		pc.msgs <- &sarama.ConsumerMessage{Topic: "topic", Partition: 0, Offset: int64(i), Value: []byte(`{}`)}
+	}
+	c := consumer{contentType: "application/json"}
+	fl := newFlow(2, "topic", 0)
+	chMsg := make(chan async.Message, 10)
+	ctx, cnl := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.consumePartition(ctx, pc, fl, nil, chMsg, make(chan error, 10))
+	}()
+	first, second := <-chMsg, <-chMsg
+	select {
+	case <-chMsg:
+		assert.Fail(t, "partition not paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.NoError(t, first.Ack())
+	assert.NoError(t, first.Nack())
+	select {
+	case msg := <-chMsg:
+		assert.Equal(t, int64(2), msg.Metadata().(Metadata).Offset)
+	case <-time.After(time.Second):
+		assert.Fail(t, "partition not resumed")
+	}
+	cnl()
+	<-done
+	assert.NotNil(t, fl.paused())
+	assert.NoError(t, second.Nack())
+	assert.Nil(t, fl.paused())
+}
+
+type mockPartitionConsumer struct {
+	msgs chan *sarama.ConsumerMessage
+}
+
+func (pc *mockPartitionConsumer) AsyncClose() {}
+
+func (pc *mockPartitionConsumer) Close() error { return nil }
+
+func (pc *mockPartitionConsumer) Messages() <-chan *sarama.ConsumerMessage { return pc.msgs }
+
+func (pc *mockPartitionConsumer) Errors() <-chan *sarama.ConsumerError { return nil }
+
+func (pc *mockPartitionConsumer) HighWaterMarkOffset() int64 { return 3 }
@@ -0,0 +1,440 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/mantzas/patron/async"
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/reliability/retry"
+)
+
+const (
+	defaultSessionTimeout    = 10 * time.Second
+	defaultHeartbeatInterval = 3 * time.Second
+	joinAttempts             = 5
+)
+
+// Balance defines how the partitions are assigned to the members of a consumer group.
+type Balance int
+
+const (
+	// RangeBalance assigns to every member a range of consecutive partitions of every topic.
+	RangeBalance Balance = iota
+	// RoundRobinBalance assigns the partitions of all topics to the members one by one.
+	RoundRobinBalance
+)
+
+// String returns the name of the balance, which is also the name of the protocol used in the group.
+func (b Balance) String() string {
+	switch b {
+	case RangeBalance:
+		return "range"
+	case RoundRobinBalance:
+		return "roundrobin"
+	default:
+		return "N/A"
+	}
+}
+
+// generation of the consumer group, along with the topics subscribed, and the partitions claimed, by the consumer
+// and their offsets.
+type generation struct {
+	id      int32
+	topics  []string
+	claims  map[string][]int32
+	offsets *offsets
+}
+
+// errRejoin is returned when the consumer has to join the group again, e.g. when a rebalance is in progress.
+var errRejoin = errors.New("group has to be joined again")
+
+func (c *consumer) setupGroup() error {
+	if c.start != OffsetNewest && c.start != OffsetOldest {
+		return errors.New("group consumers start from the newest or oldest offset")
+	}
+	if len(c.startOffsets) > 0 || len(c.partitions) > 0 {
+		return errors.New("group consumers consume the partitions assigned by the group from their committed offsets")
+	}
+	if c.heartbeat >= c.session {
+		return errors.New("heartbeat interval must be lower than the session timeout")
+	}
+	return nil
+}
+
+// consumeGroup joins the consumer group and consumes the claimed partitions. On every rebalance the
+// partitions are released and the group is joined again, until the context is done. The partitions
+// of the last generation are released, and the group is left, when the consumer is closed.
+func (c *consumer) consumeGroup(ctx context.Context) (<-chan async.Message, <-chan error, error) {
+	err := c.connect()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g, err := c.join(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Infof("consuming messages for topics %v in group '%s'", g.topics, c.group)
+	chMsg := make(chan async.Message, c.buffer)
+	chErr := make(chan error, c.buffer)
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		for {
+			err := c.consumeGeneration(ctx, g, chMsg, chErr)
+			if ctx.Err() != nil {
+				c.gen = g
+				return
+			}
+			c.release(g)
+			if err == nil {
+				g, err = c.join(ctx)
+			}
+			if err != nil {
+				chErr <- err
+				return
+			}
+		}
+	}()
+	return chMsg, chErr, nil
+}
+
+// consumeGeneration consumes the claimed partitions, starting from their committed offsets, until a rebalance
+// is required or the context is done. The partition consumers are stopped before returning.
+func (c *consumer) consumeGeneration(ctx context.Context, g *generation, chMsg chan<- async.Message, chErr chan<- error) error {
+	ctx, cnl := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cnl()
+		wg.Wait()
+	}()
+
+	for topic, partitions := range g.claims {
+		for _, partition := range partitions {
+			offset, err := c.fetchOffset(topic, partition)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get offset of partition %d", partition)
+			}
+			pc, err := c.ms.ConsumePartition(topic, partition, offset)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get partition consumer of partition %d", partition)
+			}
+			fl := c.partitionFlow(topic, partition)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.consumePartition(ctx, pc, fl, g.offsets, chMsg, chErr)
+			}()
+		}
+	}
+	log.Infof("generation %d of group '%s' claimed partitions %v", g.id, c.group, g.claims)
+	return c.maintain(ctx, g)
+}
+
+// release commits the offsets of the generation and stops tracking its messages, so that messages acknowledged
+// afterwards are redelivered to the member the partitions are assigned to.
+func (c *consumer) release(g *generation) error {
+	err := c.commit(g)
+	g.offsets.close()
+	if err != nil {
+		log.Errorf("failed to commit offsets of generation %d of group '%s': %v", g.id, c.group, err)
+	}
+	return err
+}
+
+// maintain keeps the membership of the consumer alive, with heartbeats, and commits the offsets of the acknowledged
+// messages periodically, until the context is done, returning nil when the group has to be joined again, e.g. when
+// the topics matching the topic pattern have changed.
+func (c *consumer) maintain(ctx context.Context, g *generation) error {
+	t := time.NewTicker(c.heartbeat)
+	defer t.Stop()
+	tc := time.NewTicker(c.cfg.Consumer.Offsets.CommitInterval)
+	defer tc.Stop()
+	var refresh <-chan time.Time
+	if c.pattern != nil {
+		tr := time.NewTicker(c.topicRefresh)
+		defer tr.Stop()
+		refresh = tr.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tc.C:
+			err := c.commit(g)
+			if err != nil {
+				log.Errorf("failed to commit offsets of group '%s': %v", c.group, err)
+			}
+			continue
+		case <-refresh:
+			topics, err := c.subscription()
+			if err != nil {
+				log.Errorf("failed to refresh topics matching '%s': %v", c.pattern, err)
+				continue
+			}
+			if !sameTopics(topics, g.topics) {
+				log.Infof("topics matching '%s' changed to %v, group '%s' is rejoined", c.pattern, topics, c.group)
+				return nil
+			}
+			continue
+		case <-t.C:
+		}
+		coord, err := c.client.Coordinator(c.group)
+		if err != nil {
+			return errors.Wrap(err, "failed to get group coordinator")
+		}
+		resp, err := coord.Heartbeat(&sarama.HeartbeatRequest{GroupId: c.group, GenerationId: g.id, MemberId: c.memberID})
+		if err != nil {
+			return errors.Wrap(err, "failed to send heartbeat")
+		}
+		err = c.groupError(resp.Err)
+		if err == errRejoin {
+			log.Infof("group '%s' is rebalancing", c.group)
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "heartbeat failed")
+		}
+	}
+}
+
+// join joins the consumer group and retrieves the partitions assigned to the consumer. The leader of the group
+// assigns the partitions to all members.
+func (c *consumer) join(ctx context.Context) (*generation, error) {
+	var err error
+	for i := 0; i < joinAttempts; i++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var g *generation
+		g, err = c.joinOnce()
+		if err != errRejoin {
+			return g, err
+		}
+		if retry.Sleep(ctx, c.cfg.Metadata.Retry.Backoff) != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, errors.Wrapf(err, "failed to join group '%s'", c.group)
+}
+
+func (c *consumer) joinOnce() (*generation, error) {
+	topics, err := c.subscription()
+	if err != nil {
+		return nil, err
+	}
+	coord, err := c.client.Coordinator(c.group)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get group coordinator")
+	}
+
+	req := &sarama.JoinGroupRequest{
+		GroupId:        c.group,
+		SessionTimeout: int32(c.session / time.Millisecond),
+		MemberId:       c.memberID,
+		ProtocolType:   "consumer",
+	}
+	err = req.AddGroupProtocolMetadata(c.balance.String(), &sarama.ConsumerGroupMemberMetadata{Topics: topics})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode group metadata")
+	}
+	resp, err := coord.JoinGroup(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to join group")
+	}
+	err = c.groupError(resp.Err)
+	if err != nil {
+		return nil, err
+	}
+	c.memberID = resp.MemberId
+
+	syncReq := &sarama.SyncGroupRequest{GroupId: c.group, GenerationId: resp.GenerationId, MemberId: c.memberID}
+	if resp.LeaderId == resp.MemberId {
+		err = c.assign(resp, syncReq)
+		if err != nil {
+			return nil, err
+		}
+	}
+	syncResp, err := coord.SyncGroup(syncReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sync group")
+	}
+	err = c.groupError(syncResp.Err)
+	if err != nil {
+		return nil, err
+	}
+	g := &generation{id: resp.GenerationId, topics: topics, claims: map[string][]int32{}, offsets: newOffsets()}
+	if len(syncResp.MemberAssignment) == 0 {
+		return g, nil
+	}
+	assignment, err := syncResp.GetMemberAssignment()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode group assignment")
+	}
+	g.claims = assignment.Topics
+	return g, nil
+}
+
+// assign assigns, as the group leader, the partitions of the subscribed topics to the members of the group.
+func (c *consumer) assign(resp *sarama.JoinGroupResponse, syncReq *sarama.SyncGroupRequest) error {
+	members, err := resp.GetMembers()
+	if err != nil {
+		return errors.Wrap(err, "failed to decode group members")
+	}
+	subscriptions := make(map[string][]string, len(members))
+	partitions := make(map[string][]int32)
+	for id, m := range members {
+		subscriptions[id] = m.Topics
+		for _, topic := range m.Topics {
+			if _, ok := partitions[topic]; ok {
+				continue
+			}
+			pp, err := c.client.Partitions(topic)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get partitions of topic %s", topic)
+			}
+			partitions[topic] = pp
+		}
+	}
+	balance := balanceRange
+	if c.balance == RoundRobinBalance {
+		balance = balanceRoundRobin
+	}
+	for id, claims := range balance(subscriptions, partitions) {
+		err = syncReq.AddGroupAssignmentMember(id, &sarama.ConsumerGroupMemberAssignment{Topics: claims})
+		if err != nil {
+			return errors.Wrap(err, "failed to encode group assignment")
+		}
+	}
+	return nil
+}
+
+// groupError maps the errors which require the group to be joined again to errRejoin.
+func (c *consumer) groupError(kerr sarama.KError) error {
+	switch kerr {
+	case sarama.ErrNoError:
+		return nil
+	case sarama.ErrUnknownMemberId, sarama.ErrIllegalGeneration:
+		c.memberID = ""
+		return errRejoin
+	case sarama.ErrRebalanceInProgress:
+		return errRejoin
+	case sarama.ErrNotCoordinatorForConsumer, sarama.ErrConsumerCoordinatorNotAvailable:
+		err := c.client.RefreshCoordinator(c.group)
+		if err != nil {
+			return errors.Wrap(err, "failed to refresh group coordinator")
+		}
+		return errRejoin
+	default:
+		return kerr
+	}
+}
+
+// leave leaves the group, so that its partitions are assigned to the other members without waiting
+// for the session to time out.
+func (c *consumer) leave() {
+	if c.memberID == "" {
+		return
+	}
+	coord, err := c.client.Coordinator(c.group)
+	if err != nil {
+		log.Errorf("failed to get coordinator for leaving group '%s': %v", c.group, err)
+		return
+	}
+	resp, err := coord.LeaveGroup(&sarama.LeaveGroupRequest{GroupId: c.group, MemberId: c.memberID})
+	if err == nil && resp.Err != sarama.ErrNoError {
+		err = resp.Err
+	}
+	if err != nil {
+		log.Errorf("failed to leave group '%s': %v", c.group, err)
+		return
+	}
+	c.memberID = ""
+}
+
+// balanceRange assigns to every member, sorted by ID, a range of consecutive partitions of each subscribed topic.
+func balanceRange(subscriptions map[string][]string, partitions map[string][]int32) map[string]map[string][]int32 {
+	plan := make(map[string]map[string][]int32, len(subscriptions))
+	for topic, pp := range partitions {
+		pp = sortedPartitions(pp)
+		members := subscribers(subscriptions, topic)
+		n, extra := len(pp)/len(members), len(pp)%len(members)
+		start := 0
+		for i, id := range members {
+			size := n
+			if i < extra {
+				size++
+			}
+			if size > 0 {
+				claim(plan, id, topic, pp[start:start+size]...)
+			}
+			start += size
+		}
+	}
+	return plan
+}
+
+// balanceRoundRobin assigns the partitions of all topics, sorted by topic and partition, one by one to the members,
+// sorted by ID, which are subscribed to the topic of the partition.
+func balanceRoundRobin(subscriptions map[string][]string, partitions map[string][]int32) map[string]map[string][]int32 {
+	plan := make(map[string]map[string][]int32, len(subscriptions))
+	topics := make([]string, 0, len(partitions))
+	for topic := range partitions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	var all []string
+	for id := range subscriptions {
+		all = append(all, id)
+	}
+	sort.Strings(all)
+	next := 0
+	for _, topic := range topics {
+		for _, p := range sortedPartitions(partitions[topic]) {
+			for !subscribed(subscriptions[all[next%len(all)]], topic) {
+				next++
+			}
+			claim(plan, all[next%len(all)], topic, p)
+			next++
+		}
+	}
+	return plan
+}
+
+func subscribers(subscriptions map[string][]string, topic string) []string {
+	var members []string
+	for id, topics := range subscriptions {
+		if subscribed(topics, topic) {
+			members = append(members, id)
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+func subscribed(topics []string, topic string) bool {
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedPartitions(pp []int32) []int32 {
+	sorted := append([]int32(nil), pp...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func claim(plan map[string]map[string][]int32, id, topic string, pp ...int32) {
+	if plan[id] == nil {
+		plan[id] = make(map[string][]int32)
+	}
+	plan[id][topic] = append(plan[id][topic], pp...)
+}
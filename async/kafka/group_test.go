@@ -0,0 +1,170 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalance_String(t *testing.T) {
+	assert.Equal(t, "range", RangeBalance.String())
+	assert.Equal(t, "roundrobin", RoundRobinBalance.String())
+	assert.Equal(t, "N/A", Balance(5).String())
+}
+
+func TestNewGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   string
+		topic   string
+		oo      []OptionFunc
+		wantErr bool
+	}{
+		{name: "success", group: "group", topic: "topic", wantErr: false},
+		{name: "failed, missing group", group: "", topic: "topic", wantErr: true},
+		{name: "failed, missing topic", group: "group", topic: "", wantErr: true},
+		{name: "success, topics without topic", group: "group", topic: "", oo: []OptionFunc{Topics("topic"), Rebalance(RoundRobinBalance)}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewGroup("test", "application/json", tt.group, tt.topic, []string{"192.168.1.1"}, tt.oo...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "group", got.group)
+			}
+		})
+	}
+}
+
+func TestFactory_Create_Group(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   string
+		oo      []OptionFunc
+		wantErr bool
+	}{
+		{name: "success", group: "group", oo: []OptionFunc{Rebalance(RoundRobinBalance), SessionTimeout(time.Minute), HeartbeatInterval(time.Second), CommitInterval(5 * time.Second), Start(OffsetOldest)}},
+		{name: "failed, group option without group", group: "", oo: []OptionFunc{Rebalance(RoundRobinBalance)}, wantErr: true},
+		{name: "failed, invalid balance", group: "group", oo: []OptionFunc{Rebalance(5)}, wantErr: true},
+		{name: "failed, invalid session timeout", group: "group", oo: []OptionFunc{SessionTimeout(0)}, wantErr: true},
+		{name: "failed, invalid heartbeat interval", group: "group", oo: []OptionFunc{HeartbeatInterval(-1)}, wantErr: true},
+		{name: "failed, heartbeat exceeds session", group: "group", oo: []OptionFunc{SessionTimeout(time.Second), HeartbeatInterval(time.Second)}, wantErr: true},
+		{name: "failed, commit interval without group", group: "", oo: []OptionFunc{CommitInterval(time.Second)}, wantErr: true},
+		{name: "failed, invalid commit interval", group: "group", oo: []OptionFunc{CommitInterval(0)}, wantErr: true},
+		{name: "failed, start offsets", group: "group", oo: []OptionFunc{StartOffsets(map[int32]int64{0: 1})}, wantErr: true},
+		{name: "failed, partitions", group: "group", oo: []OptionFunc{Partitions(0)}, wantErr: true},
+		{name: "failed, explicit start offset", group: "group", oo: []OptionFunc{Start(10)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Factory{name: "test", topic: "topic", group: tt.group, brokers: []string{"192.168.1.1"}, oo: tt.oo}
+			got, err := f.Create()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "group", got.Info()["group"])
+				assert.Equal(t, "roundrobin", got.Info()["balance"])
+				assert.Equal(t, "5s", got.Info()["commit-interval"])
+				assert.Equal(t, OffsetOldest, got.(*consumer).start)
+			}
+		})
+	}
+}
+
+func Test_balanceRange(t *testing.T) {
+	subscriptions := map[string][]string{"b": {"t1", "t2"}, "a": {"t1", "t2"}, "c": {"t1"}}
+	partitions := map[string][]int32{"t1": {4, 3, 2, 1, 0}, "t2": {0, 1}}
+	expected := map[string]map[string][]int32{
+		"a": {"t1": {0, 1}, "t2": {0}},
+		"b": {"t1": {2, 3}, "t2": {1}},
+		"c": {"t1": {4}},
+	}
+	assert.Equal(t, expected, balanceRange(subscriptions, partitions))
+}
+
+func Test_balanceRoundRobin(t *testing.T) {
+	subscriptions := map[string][]string{"b": {"t1", "t2"}, "a": {"t1", "t2"}, "c": {"t1"}}
+	partitions := map[string][]int32{"t1": {2, 1, 0}, "t2": {0, 1, 2}}
+	expected := map[string]map[string][]int32{
+		"a": {"t1": {0}, "t2": {0, 2}},
+		"b": {"t1": {1}, "t2": {1}},
+		"c": {"t1": {2}},
+	}
+	assert.Equal(t, expected, balanceRoundRobin(subscriptions, partitions))
+}
+
+func TestConsumer_Group(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	join := &sarama.JoinGroupRequest{}
+	assert.NoError(t, join.AddGroupProtocolMetadata("range", &sarama.ConsumerGroupMemberMetadata{Topics: []string{"topic"}}))
+	assignment := &sarama.SyncGroupRequest{}
+	assert.NoError(t, assignment.AddGroupAssignmentMember("member", &sarama.ConsumerGroupMemberAssignment{Topics: map[string][]int32{"topic": {0}}}))
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("topic", 0, broker.BrokerID()).
+			SetLeader("topic", 1, broker.BrokerID()),
+		"ConsumerMetadataRequest": sarama.NewMockConsumerMetadataResponse(t).SetCoordinator("group", broker),
+		"JoinGroupRequest": sarama.NewMockWrapper(&sarama.JoinGroupResponse{
+			GenerationId:  1,
+			GroupProtocol: "range",
+			LeaderId:      "member",
+			MemberId:      "member",
+			Members:       map[string][]byte{"member": join.OrderedGroupProtocols[0].Metadata},
+		}),
+		"SyncGroupRequest":    sarama.NewMockWrapper(&sarama.SyncGroupResponse{MemberAssignment: assignment.GroupAssignments["member"]}),
+		"HeartbeatRequest":    sarama.NewMockWrapper(&sarama.HeartbeatResponse{}),
+		"LeaveGroupRequest":   sarama.NewMockWrapper(&sarama.LeaveGroupResponse{}),
+		"OffsetFetchRequest":  sarama.NewMockOffsetFetchResponse(t).SetOffset("group", "topic", 0, 5, "", sarama.ErrNoError),
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).SetVersion(1).
+			SetOffset("topic", 0, sarama.OffsetOldest, 0).
+			SetOffset("topic", 0, sarama.OffsetNewest, 10),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(3).
+			SetMessage("topic", 0, 5, sarama.StringEncoder(`{"key":"value"}`)).
+			SetHighWaterMark("topic", 0, 10),
+	})
+
+	f, err := NewGroup("name", "application/json", "group", "topic", []string{broker.Addr()},
+		Version("0.10.2.0"), HeartbeatInterval(10*time.Millisecond))
+	assert.NoError(t, err)
+	cns, err := f.Create()
+	assert.NoError(t, err)
+	chMsg, chErr, err := cns.Consume(context.Background())
+	assert.NoError(t, err)
+	select {
+	case msg := <-chMsg:
+		md := msg.Metadata().(Metadata)
+		assert.Equal(t, "topic", md.Topic)
+		assert.Equal(t, int32(0), md.Partition)
+		assert.Equal(t, int64(5), md.Offset)
+		assert.NoError(t, msg.Ack())
+	case err := <-chErr:
+		assert.Fail(t, "unexpected error", err)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no message received")
+	}
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, cns.Close())
+	assert.Empty(t, cns.(*consumer).memberID)
+	var commits []*sarama.OffsetCommitRequest
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.OffsetCommitRequest); ok {
+			commits = append(commits, req)
+		}
+	}
+	assert.Len(t, commits, 1)
+	assert.Equal(t, int32(1), commits[0].ConsumerGroupGeneration)
+	assert.Equal(t, "member", commits[0].ConsumerID)
+}
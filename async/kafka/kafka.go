@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/google/uuid"
 	"github.com/mantzas/patron/async"
 	"github.com/mantzas/patron/encoding"
 	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/health"
 	"github.com/mantzas/patron/log"
 	"github.com/mantzas/patron/trace"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -33,11 +37,25 @@ func init() {
 	prometheus.MustRegister(topicPartitionOffsetDiff)
 }
 
+// Metadata of a Kafka message.
+type Metadata struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Timestamp time.Time
+}
+
 type message struct {
-	span opentracing.Span
-	ctx  context.Context
-	dec  encoding.DecodeRawFunc
-	val  []byte
+	span     opentracing.Span
+	ctx      context.Context
+	dec      encoding.DecodeRawFunc
+	val      []byte
+	headers  map[string]string
+	md       Metadata
+	offsets  *offsets
+	flow     *flow
+	released int32
 }
 
 func (m *message) Context() context.Context {
@@ -48,16 +66,69 @@ func (m *message) Decode(v interface{}) error {
 	return m.dec(m.val, v)
 }
 
+// Body returns the raw value of the message.
+func (m *message) Body() []byte {
+	return m.val
+}
+
+// Headers returns the headers of the message.
+func (m *message) Headers() map[string]string {
+	return m.headers
+}
+
+// Metadata returns the Metadata of the message.
+func (m *message) Metadata() interface{} {
+	return m.md
+}
+
+// Ack marks the offset of the message for commit, when consumed in a group.
 func (m *message) Ack() error {
+	if m.offsets != nil {
+		m.offsets.ack(m.md.Topic, m.md.Partition, m.md.Offset)
+	}
+	m.release()
 	trace.SpanSuccess(m.span)
 	return nil
 }
 
+// Nack leaves the offset of the message uncommitted, when consumed in a group, so that the committed offset
+// of the partition does not advance past it and the message is consumed again after a restart or rebalance.
 func (m *message) Nack() error {
+	if m.offsets != nil {
+		m.offsets.nack(m.md.Topic, m.md.Partition, m.md.Offset)
+	}
+	m.release()
 	trace.SpanError(m.span)
 	return nil
 }
 
+// release releases the message from the flow of its partition, once.
+func (m *message) release() {
+	if m.flow != nil && atomic.CompareAndSwapInt32(&m.released, 0, 1) {
+		m.flow.release()
+	}
+}
+
+// PartitionKey is a key func which extracts the topic and partition of a Kafka message,
+// so that the async component processes the messages of a partition in order.
+func PartitionKey(msg async.Message) string {
+	md, ok := msg.Metadata().(Metadata)
+	if !ok {
+		return ""
+	}
+	return md.Topic + "/" + strconv.FormatInt(int64(md.Partition), 10)
+}
+
+// MessageKey is a key func which extracts the key of a Kafka message,
+// so that the async component processes the messages with the same key in order.
+func MessageKey(msg async.Message) string {
+	md, ok := msg.Metadata().(Metadata)
+	if !ok {
+		return ""
+	}
+	return string(md.Key)
+}
+
 // Offset defines the offset of messages inside a topic.
 type Offset int64
 
@@ -84,12 +155,28 @@ type Factory struct {
 	name    string
 	ct      string
 	topic   string
+	group   string
 	brokers []string
 	oo      []OptionFunc
 }
 
-// New constructor.
+// New constructor. The topic may be empty, when the topics to consume are provided
+// with the Topics or TopicPattern option.
 func New(name, ct, topic string, brokers []string, oo ...OptionFunc) (*Factory, error) {
+	return newFactory(name, ct, "", topic, brokers, oo)
+}
+
+// NewGroup constructor of a factory of consumers, which join the consumer group and consume
+// only the partitions assigned to them. Offsets are tracked per group, with the start offset applying
+// to partitions without a committed offset.
+func NewGroup(name, ct, group, topic string, brokers []string, oo ...OptionFunc) (*Factory, error) {
+	if group == "" {
+		return nil, errors.New("group is required")
+	}
+	return newFactory(name, ct, group, topic, brokers, oo)
+}
+
+func newFactory(name, ct, group, topic string, brokers []string, oo []OptionFunc) (*Factory, error) {
 
 	if name == "" {
 		return nil, errors.New("name is required")
@@ -99,11 +186,15 @@ func New(name, ct, topic string, brokers []string, oo ...OptionFunc) (*Factory,
 		return nil, errors.New("provide at least one broker")
 	}
 
+	f := &Factory{name: name, ct: ct, topic: topic, group: group, brokers: brokers, oo: oo}
 	if topic == "" {
-		return nil, errors.New("topic is required")
+		// the topics are then provided with options, which are validated by applying them to a consumer
+		_, err := f.newConsumer()
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	return &Factory{name: name, ct: ct, topic: topic, brokers: brokers, oo: oo}, nil
+	return f, nil
 }
 
 // Create a new consumer.
@@ -114,8 +205,27 @@ func (f *Factory) Create() (async.Consumer, error) {
 		return nil, errors.New("failed to get hostname")
 	}
 
+	c, err := f.newConsumer()
+	if err != nil {
+		return nil, err
+	}
+	c.cfg.ClientID = fmt.Sprintf("%s-%s", host, f.name)
+
+	if c.group != "" {
+		err = c.setupGroup()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.createInfo()
+	return c, nil
+}
+
+// newConsumer returns a consumer with the defaults and the options of the factory applied.
+func (f *Factory) newConsumer() (*consumer, error) {
+
 	config := sarama.NewConfig()
-	config.ClientID = fmt.Sprintf("%s-%s", host, f.name)
 	config.Consumer.Return.Errors = true
 	config.Version = sarama.V0_11_0_0
 
@@ -127,16 +237,28 @@ func (f *Factory) Create() (async.Consumer, error) {
 		buffer:      1000,
 		start:       OffsetNewest,
 		info:        make(map[string]interface{}),
+		group:       f.group,
+		balance:     RangeBalance,
+		session:     defaultSessionTimeout,
+		heartbeat:   defaultHeartbeatInterval,
+
+		topicRefresh: defaultTopicRefresh,
 	}
 
 	for _, o := range f.oo {
-		err = o(c)
+		err := o(c)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	c.createInfo()
+	if c.topic == "" && len(c.topics) == 0 && c.pattern == nil {
+		return nil, errors.New("topic is required")
+	}
+
+	if (len(c.topics) > 0 || c.pattern != nil) && (len(c.partitions) > 0 || len(c.startOffsets) > 0) {
+		return nil, errors.New("partitions and start offsets apply only to consumers of a single topic")
+	}
 	return c, nil
 }
 
@@ -148,8 +270,25 @@ type consumer struct {
 	cfg         *sarama.Config
 	contentType string
 	cnl         context.CancelFunc
+	client      sarama.Client
 	ms          sarama.Consumer
+	stopped     int32
 	info        map[string]interface{}
+	group       string
+	balance     Balance
+	session     time.Duration
+	heartbeat   time.Duration
+	memberID    string
+	gen         *generation
+	done        chan struct{}
+
+	partitionInFlight int
+	startTime         time.Time
+	startOffsets      map[int32]int64
+	partitions        []int32
+	topics            []string
+	pattern           *regexp.Regexp
+	topicRefresh      time.Duration
 }
 
 // Info return the information of the consumer.
@@ -157,75 +296,145 @@ func (c *consumer) Info() map[string]interface{} {
 	return c.info
 }
 
-// Consume starts consuming messages from a Kafka topic.
+// Consume starts consuming messages from the Kafka topics.
 func (c *consumer) Consume(ctx context.Context) (<-chan async.Message, <-chan error, error) {
 	ctx, cnl := context.WithCancel(ctx)
 	c.cnl = cnl
 
-	pcs, err := c.consumers()
+	if c.group != "" {
+		return c.consumeGroup(ctx)
+	}
+
+	err := c.connect()
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to get partitions")
 	}
-	log.Infof("consuming messages for topic '%s'", c.topic)
+	topics, err := c.subscription()
+	if err != nil {
+		return nil, nil, err
+	}
 	chMsg := make(chan async.Message, c.buffer)
 	chErr := make(chan error, c.buffer)
+	for _, topic := range topics {
+		err = c.consumeTopic(ctx, topic, chMsg, chErr)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to get partitions")
+		}
+	}
+	log.Infof("consuming messages for topics %v", topics)
+
+	if c.pattern != nil {
+		c.done = make(chan struct{})
+		go c.watchTopics(ctx, topics, chMsg, chErr)
+	}
+	return chMsg, chErr, nil
+}
 
-	for _, pc := range pcs {
-		go func(consumer sarama.PartitionConsumer) {
-			for {
+// consumePartition sends the messages of the partition consumer to the message channel until the context is done
+// or the partition consumer fails. The delivered messages are tracked by the offsets of group consumers, and
+// by the flow of the partition, if set, which pauses the consumption while too many messages are in-flight.
+func (c *consumer) consumePartition(ctx context.Context, pc sarama.PartitionConsumer, fl *flow, oo *offsets,
+	chMsg chan<- async.Message, chErr chan<- error) {
+	if fl != nil {
+		defer fl.stop()
+	}
+	for {
+		if fl != nil {
+			if resumed := fl.paused(); resumed != nil {
 				select {
 				case <-ctx.Done():
 					log.Info("canceling consuming messages requested")
-					closeConsumer(consumer)
+					closeConsumer(pc)
 					return
-				case consumerError := <-consumer.Errors():
-					closeConsumer(consumer)
-					chErr <- consumerError
-					return
-				case m := <-consumer.Messages():
-					log.Debugf("data received from topic %s", m.Topic)
-					topicPartitionOffsetDiffGaugeSet(m.Topic, m.Partition, consumer.HighWaterMarkOffset(), m.Offset)
-					go func(msg *sarama.ConsumerMessage) {
-						sp, chCtx := trace.ConsumerSpan(
-							ctx,
-							trace.ComponentOpName(trace.KafkaConsumerComponent, msg.Topic),
-							trace.KafkaConsumerComponent,
-							mapHeader(msg.Headers),
-						)
-						var ct string
-						if c.contentType != "" {
-							ct = c.contentType
-						} else {
-							ct, err = determineContentType(msg.Headers)
-							if err != nil {
-								trace.SpanError(sp)
-								chErr <- errors.Wrap(err, "failed to determine content type")
-								return
-							}
-						}
-
-						dec, err := async.DetermineDecoder(ct)
-						if err != nil {
-							trace.SpanError(sp)
-							chErr <- errors.Wrapf(err, "failed to determine decoder for %s", ct)
-							return
-						}
-
-						chCtx = log.WithContext(chCtx, log.Sub(map[string]interface{}{"messageID": uuid.New().String()}))
-
-						chMsg <- &message{
-							ctx:  chCtx,
-							dec:  dec,
-							span: sp,
-							val:  msg.Value,
-						}
-					}(m)
+				case <-resumed:
 				}
 			}
-		}(pc)
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("canceling consuming messages requested")
+			closeConsumer(pc)
+			return
+		case consumerError := <-pc.Errors():
+			atomic.AddInt32(&c.stopped, 1)
+			closeConsumer(pc)
+			chErr <- consumerError
+			return
+		case m := <-pc.Messages():
+			log.Debugf("data received from topic %s", m.Topic)
+			topicPartitionOffsetDiffGaugeSet(m.Topic, m.Partition, pc.HighWaterMarkOffset(), m.Offset)
+			msg, err := c.message(ctx, m)
+			if err != nil {
+				chErr <- err
+				continue
+			}
+			if oo != nil {
+				oo.deliver(m.Topic, m.Partition, m.Offset)
+				msg.offsets = oo
+			}
+			if fl != nil {
+				fl.deliver()
+				msg.flow = fl
+			}
+			select {
+			case chMsg <- msg:
+			case <-ctx.Done():
+				log.Info("canceling consuming messages requested")
+				closeConsumer(pc)
+				return
+			}
+		}
 	}
+}
 
-	return chMsg, chErr, nil
+// message creates a message from the consumer message. Messages are created in the order they are received
+// from the partition, so that the order of the partition can be preserved by the async component.
+func (c *consumer) message(ctx context.Context, msg *sarama.ConsumerMessage) (*message, error) {
+	hh := mapHeader(msg.Headers)
+	sp, chCtx := trace.ConsumerSpan(
+		ctx,
+		trace.ComponentOpName(trace.KafkaConsumerComponent, msg.Topic),
+		trace.KafkaConsumerComponent,
+		hh,
+		opentracing.Tag{Key: "topic", Value: msg.Topic},
+		opentracing.Tag{Key: "partition", Value: msg.Partition},
+		opentracing.Tag{Key: "offset", Value: msg.Offset},
+	)
+	ct := c.contentType
+	if ct == "" {
+		var err error
+		ct, err = determineContentType(msg.Headers)
+		if err != nil {
+			trace.SpanError(sp)
+			return nil, errors.Wrap(err, "failed to determine content type")
+		}
+	}
+
+	dec, err := async.DetermineDecoder(ct)
+	if err != nil {
+		trace.SpanError(sp)
+		return nil, errors.Wrapf(err, "failed to determine decoder for %s", ct)
+	}
+	if _, ok := hh[encoding.ContentTypeHeader]; !ok {
+		hh[encoding.ContentTypeHeader] = ct
+	}
+
+	chCtx = log.WithContext(chCtx, log.Sub(map[string]interface{}{"messageID": uuid.New().String()}))
+
+	return &message{
+		ctx:     chCtx,
+		dec:     dec,
+		span:    sp,
+		val:     msg.Value,
+		headers: hh,
+		md: Metadata{
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+			Key:       msg.Key,
+			Timestamp: msg.Timestamp,
+		},
+	}, nil
 }
 
 // Close handles closing consumer.
@@ -233,48 +442,176 @@ func (c *consumer) Close() error {
 	if c.cnl != nil {
 		c.cnl()
 	}
+	if c.done != nil {
+		<-c.done
+	}
 
-	if c.ms == nil {
-		return nil
+	var errCommit, errCns, errClient error
+	if c.gen != nil {
+		errCommit = c.release(c.gen)
+		c.leave()
+	}
+	if c.ms != nil {
+		errCns = errors.Wrap(c.ms.Close(), "failed to close consumer")
+	}
+	if c.client != nil {
+		errClient = errors.Wrap(c.client.Close(), "failed to close client")
+	}
+	return errors.Aggregate(errCommit, errCns, errClient)
+}
+
+// Health reports the consumer as down when the consumption of any partition has stopped.
+func (c *consumer) Health() health.Status {
+	if atomic.LoadInt32(&c.stopped) > 0 {
+		return health.Down
 	}
+	return health.Up
+}
 
-	return errors.Wrap(c.ms.Close(), "failed to close consumer")
+// Check reports whether the brokers are reachable by refreshing the metadata of the topic.
+func (c *consumer) Check(ctx context.Context) error {
+	if c.client == nil {
+		return errors.New("consumer is not connected")
+	}
+	var topics []string
+	if c.pattern == nil {
+		topics = c.configuredTopics()
+	}
+	return errors.Wrap(c.client.RefreshMetadata(topics...), "failed to reach brokers")
 }
 
-func (c *consumer) consumers() ([]sarama.PartitionConsumer, error) {
+func (c *consumer) connect() error {
+	client, err := sarama.NewClient(c.brokers, c.cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to create client")
+	}
+	c.client = client
 
-	ms, err := sarama.NewConsumer(c.brokers, c.cfg)
+	ms, err := sarama.NewConsumerFromClient(client)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create consumer")
+		return errors.Wrap(err, "failed to create consumer")
 	}
 	c.ms = ms
+	return nil
+}
 
-	partitions, err := c.ms.Partitions(c.topic)
+// consumeTopic consumes the partitions of the topic, or the selected partitions, until the context is done.
+func (c *consumer) consumeTopic(ctx context.Context, topic string, chMsg chan<- async.Message, chErr chan<- error) error {
+	partitions, err := c.ms.Partitions(topic)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get partitions")
+		return errors.Wrapf(err, "failed to get partitions of topic %s", topic)
+	}
+	if len(c.partitions) > 0 {
+		partitions, err = selectPartitions(partitions, c.partitions)
+		if err != nil {
+			return err
+		}
+	}
+	for partition := range c.startOffsets {
+		if !containsPartition(partitions, partition) {
+			return errors.Errorf("start offset provided for partition %d, which is not consumed", partition)
+		}
 	}
 
-	pcs := make([]sarama.PartitionConsumer, len(partitions))
+	pcs := make(map[int32]sarama.PartitionConsumer, len(partitions))
 
-	for i, partition := range partitions {
+	for _, partition := range partitions {
 
-		pc, err := c.ms.ConsumePartition(c.topic, partition, int64(c.start))
+		offset, err := c.startOffset(topic, partition)
+		if err != nil {
+			return err
+		}
+		pc, err := c.ms.ConsumePartition(topic, partition, offset)
 		if nil != err {
-			return nil, errors.Wrap(err, "failed to get partition consumer")
+			return errors.Wrap(err, "failed to get partition consumer")
+		}
+		pcs[partition] = pc
+	}
+
+	for partition, pc := range pcs {
+		go c.consumePartition(ctx, pc, c.partitionFlow(topic, partition), nil, chMsg, chErr)
+	}
+	return nil
+}
+
+// startOffset returns the offset the partition is consumed from, which is the start offset provided for the partition,
+// the offset of the first message at or after the start time, when set, or the start offset.
+func (c *consumer) startOffset(topic string, partition int32) (int64, error) {
+	if offset, ok := c.startOffsets[partition]; ok {
+		return offset, nil
+	}
+	if c.startTime.IsZero() {
+		return int64(c.start), nil
+	}
+	offset, err := c.client.GetOffset(topic, partition, c.startTime.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get offset of partition %d at %v", partition, c.startTime)
+	}
+	return offset, nil
+}
+
+// selectPartitions returns the selected partitions, which have to exist in the topic.
+func selectPartitions(partitions, selected []int32) ([]int32, error) {
+	for _, partition := range selected {
+		if !containsPartition(partitions, partition) {
+			return nil, errors.Errorf("partition %d does not exist", partition)
+		}
+	}
+	return selected, nil
+}
+
+func containsPartition(partitions []int32, partition int32) bool {
+	for _, p := range partitions {
+		if p == partition {
+			return true
 		}
-		pcs[i] = pc
 	}
+	return false
+}
 
-	return pcs, nil
+// partitionFlow returns the flow of the partition, or nil when the in-flight messages of partitions are not limited.
+func (c *consumer) partitionFlow(topic string, partition int32) *flow {
+	if c.partitionInFlight == 0 {
+		return nil
+	}
+	return newFlow(c.partitionInFlight, topic, partition)
 }
 
 func (c *consumer) createInfo() {
 	c.info["type"] = "kafka-consumer"
 	c.info["brokers"] = strings.Join(c.brokers, ",")
-	c.info["topic"] = c.topic
+	if c.topic != "" {
+		c.info["topic"] = c.topic
+	}
+	if len(c.topics) > 0 {
+		c.info["topics"] = strings.Join(c.topics, ",")
+	}
+	if c.pattern != nil {
+		c.info["topic-pattern"] = c.pattern.String()
+		c.info["topic-refresh"] = c.topicRefresh.String()
+	}
 	c.info["buffer"] = c.buffer
 	c.info["default-content-type"] = c.contentType
 	c.info["start"] = c.start.String()
+	if !c.startTime.IsZero() {
+		c.info["start-time"] = c.startTime.Format(time.RFC3339)
+	}
+	if len(c.startOffsets) > 0 {
+		c.info["start-offsets"] = c.startOffsets
+	}
+	if len(c.partitions) > 0 {
+		c.info["partitions"] = c.partitions
+	}
+	if c.partitionInFlight > 0 {
+		c.info["partition-max-in-flight"] = c.partitionInFlight
+	}
+	if c.group != "" {
+		c.info["group"] = c.group
+		c.info["balance"] = c.balance.String()
+		c.info["session-timeout"] = c.session.String()
+		c.info["heartbeat-interval"] = c.heartbeat.String()
+		c.info["commit-interval"] = c.cfg.Consumer.Offsets.CommitInterval.String()
+	}
 }
 
 func closeConsumer(cns sarama.PartitionConsumer) {
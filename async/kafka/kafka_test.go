@@ -3,9 +3,12 @@ package kafka
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/mantzas/patron/async"
 	"github.com/mantzas/patron/encoding"
+	"github.com/mantzas/patron/health"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
@@ -59,6 +62,16 @@ func TestNew(t *testing.T) {
 			args:    args{name: "test", brokers: brokers, topic: ""},
 			wantErr: true,
 		},
+		{
+			name:    "fails with invalid option without topic",
+			args:    args{name: "test", brokers: brokers, topic: "", options: []OptionFunc{Topics("topic1"), Buffer(-1)}},
+			wantErr: true,
+		},
+		{
+			name:    "success without topic",
+			args:    args{name: "test", brokers: brokers, topic: "", options: []OptionFunc{Topics("topic1")}},
+			wantErr: false,
+		},
 		{
 			name:    "success",
 			args:    args{name: "test", brokers: brokers, topic: "topic1"},
@@ -85,18 +98,23 @@ func TestFactory_Create(t *testing.T) {
 	}
 	tests := []struct {
 		name    string
+		topic   string
 		fields  fields
 		wantErr bool
 	}{
-		{name: "success", wantErr: false},
-		{name: "failed with invalid option", fields: fields{oo: []OptionFunc{Buffer(-100)}}, wantErr: true},
+		{name: "success", topic: "topic", wantErr: false},
+		{name: "success with topic pattern only", fields: fields{oo: []OptionFunc{TopicPattern(`^orders\.`)}}, wantErr: false},
+		{name: "failed without topics", wantErr: true},
+		{name: "failed with invalid option", topic: "topic", fields: fields{oo: []OptionFunc{Buffer(-100)}}, wantErr: true},
+		{name: "failed with partitions of several topics", topic: "topic", fields: fields{oo: []OptionFunc{Topics("other"), Partitions(0)}}, wantErr: true},
+		{name: "failed with start offsets of topic pattern", topic: "topic", fields: fields{oo: []OptionFunc{TopicPattern(".*"), StartOffsets(map[int32]int64{0: 1})}}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			f := &Factory{
 				name:    "test",
 				ct:      "",
-				topic:   "topic",
+				topic:   tt.topic,
 				brokers: []string{"192.168.1.1"},
 				oo:      tt.fields.oo,
 			}
@@ -145,6 +163,180 @@ func Test_determineContentType(t *testing.T) {
 	}
 }
 
+func TestConsumer_Check(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("topic", 0, broker.BrokerID()),
+	})
+	f, err := New("name", "application/json", "topic", []string{broker.Addr()})
+	assert.NoError(t, err)
+	cns, err := f.Create()
+	assert.NoError(t, err)
+	c := cns.(*consumer)
+	assert.Error(t, c.Check(context.Background()))
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	assert.NoError(t, err)
+	c.client = client
+	assert.NoError(t, c.Check(context.Background()))
+	assert.NoError(t, c.Close())
+}
+
+func TestConsumer_Check_Topics(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("audit", 0, broker.BrokerID()).
+			SetLeader("events", 0, broker.BrokerID()),
+	})
+	tests := []struct {
+		name  string
+		topic string
+		oo    []OptionFunc
+		want  []string
+	}{
+		{name: "topic and topics", topic: "events", oo: []OptionFunc{Topics("audit")}, want: []string{"events", "audit"}},
+		{name: "topics only", oo: []OptionFunc{Topics("audit")}, want: []string{"audit"}},
+		{name: "topic pattern refreshes all topics", oo: []OptionFunc{TopicPattern(`^orders\.`)}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New("name", "application/json", tt.topic, []string{broker.Addr()}, tt.oo...)
+			assert.NoError(t, err)
+			cns, err := f.Create()
+			assert.NoError(t, err)
+			c := cns.(*consumer)
+			client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+			assert.NoError(t, err)
+			c.client = client
+			assert.NoError(t, c.Check(context.Background()))
+			hh := broker.History()
+			req, ok := hh[len(hh)-1].Request.(*sarama.MetadataRequest)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, append([]string{}, req.Topics...))
+			assert.NoError(t, c.Close())
+		})
+	}
+}
+
+func TestConsumer_Consume_StartOffsets(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	start := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	ts := start.UnixNano() / int64(time.Millisecond)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("topic", 0, broker.BrokerID()).
+			SetLeader("topic", 1, broker.BrokerID()).
+			SetLeader("topic", 2, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).SetVersion(1).
+			SetOffset("topic", 0, sarama.OffsetOldest, 0).
+			SetOffset("topic", 0, sarama.OffsetNewest, 10).
+			SetOffset("topic", 2, sarama.OffsetOldest, 0).
+			SetOffset("topic", 2, sarama.OffsetNewest, 10).
+			SetOffset("topic", 2, ts, 7),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(3).
+			SetMessage("topic", 0, 5, sarama.StringEncoder(`{}`)).
+			SetMessage("topic", 2, 7, sarama.StringEncoder(`{}`)).
+			SetHighWaterMark("topic", 0, 10).
+			SetHighWaterMark("topic", 2, 10),
+	})
+
+	tests := []struct {
+		name    string
+		oo      []OptionFunc
+		wantErr bool
+	}{
+		{name: "success", oo: []OptionFunc{Partitions(0, 2), StartOffsets(map[int32]int64{0: 5}), StartTime(start)}},
+		{name: "failed, missing partition", oo: []OptionFunc{Partitions(0, 3)}, wantErr: true},
+		{name: "failed, start offset of partition not consumed", oo: []OptionFunc{Partitions(0, 2), StartOffsets(map[int32]int64{1: 5})}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New("name", "application/json", "topic", []string{broker.Addr()}, append(tt.oo, Version("0.10.2.0"))...)
+			assert.NoError(t, err)
+			cns, err := f.Create()
+			assert.NoError(t, err)
+			defer func() { assert.NoError(t, cns.Close()) }()
+			chMsg, _, err := cns.Consume(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			offsets := make(map[int32]int64)
+			for i := 0; i < 2; i++ {
+				select {
+				case msg := <-chMsg:
+					md := msg.Metadata().(Metadata)
+					offsets[md.Partition] = md.Offset
+				case <-time.After(5 * time.Second):
+					assert.FailNow(t, "no message received")
+				}
+			}
+			assert.Equal(t, map[int32]int64{0: 5, 2: 7}, offsets)
+		})
+	}
+}
+
+func TestConsumer_Consume_TopicPattern(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	metadata := func(topics ...string) *sarama.MockMetadataResponse {
+		md := sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID())
+		for _, topic := range topics {
+			md.SetLeader(topic, 0, broker.BrokerID())
+		}
+		return md
+	}
+	offsets := sarama.NewMockOffsetResponse(t).SetVersion(1)
+	for _, topic := range []string{"events", "audit", "orders.created", "orders.deleted"} {
+		offsets.SetOffset(topic, 0, sarama.OffsetOldest, 0).SetOffset(topic, 0, sarama.OffsetNewest, 0)
+	}
+	fetch := sarama.NewMockFetchResponse(t, 1).SetVersion(3).SetMessage("orders.deleted", 0, 0, sarama.StringEncoder(`{}`))
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadata("events", "audit", "orders.created", "other"),
+		"OffsetRequest":   offsets,
+		"FetchRequest":    fetch,
+	})
+
+	f, err := New("name", "application/json", "events", []string{broker.Addr()}, Version("0.10.2.0"),
+		Topics("audit"), TopicPattern(`^orders\.`), TopicRefresh(10*time.Millisecond))
+	assert.NoError(t, err)
+	cns, err := f.Create()
+	assert.NoError(t, err)
+	chMsg, _, err := cns.Consume(context.Background())
+	assert.NoError(t, err)
+	topics, err := cns.(*consumer).subscription()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"audit", "events", "orders.created"}, topics)
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadata("events", "audit", "orders.created", "orders.deleted"),
+		"OffsetRequest":   offsets,
+		"FetchRequest":    fetch,
+	})
+	select {
+	case msg := <-chMsg:
+		assert.Equal(t, "orders.deleted", msg.Metadata().(Metadata).Topic)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no message received from new topic")
+	}
+	assert.NoError(t, cns.Close())
+}
+
+func TestConsumer_Health(t *testing.T) {
+	c := consumer{}
+	assert.Equal(t, health.Up, c.Health())
+	c.stopped = 1
+	assert.Equal(t, health.Down, c.Health())
+}
+
 func TestConsumer_Info(t *testing.T) {
 	f, err := New("name", "application/json", "topic", []string{"1", "2"})
 	assert.NoError(t, err)
@@ -166,13 +358,16 @@ func Test_message(t *testing.T) {
 	sp := opentracing.StartSpan("test")
 	ctx := context.Background()
 	msg := message{
-		ctx:  ctx,
-		dec:  json.DecodeRaw,
-		span: sp,
-		val:  []byte(`{"key":"value"}`),
+		ctx:     ctx,
+		dec:     json.DecodeRaw,
+		span:    sp,
+		val:     []byte(`{"key":"value"}`),
+		headers: map[string]string{"key": "value"},
 	}
 
 	assert.NotNil(t, msg.Context())
+	assert.Equal(t, []byte(`{"key":"value"}`), msg.Body())
+	assert.Equal(t, map[string]string{"key": "value"}, msg.Headers())
 	assert.NoError(t, msg.Ack())
 	assert.NoError(t, msg.Nack())
 	m := make(map[string]string)
@@ -180,6 +375,56 @@ func Test_message(t *testing.T) {
 	assert.Equal(t, "value", m["key"])
 }
 
+func TestConsumer_message(t *testing.T) {
+	tests := []struct {
+		name    string
+		ct      string
+		headers []*sarama.RecordHeader
+		wantErr bool
+	}{
+		{"success with default content type", json.Type, nil, false},
+		{"success with content type header", "", []*sarama.RecordHeader{{Key: []byte(encoding.ContentTypeHeader), Value: []byte(json.Type)}}, false},
+		{"failure missing content type", "", nil, true},
+		{"failure unsupported content type", "text/plain", nil, true},
+	}
+	opentracing.SetGlobalTracer(mocktracer.New())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := consumer{contentType: tt.ct}
+			ts := time.Now()
+			cm := &sarama.ConsumerMessage{Topic: "topic", Partition: 3, Offset: 10, Key: []byte("key"), Value: []byte(`{}`), Headers: tt.headers, Timestamp: ts}
+			msg, err := c.message(context.Background(), cm)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, msg)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "topic/3", PartitionKey(msg))
+				assert.Equal(t, "key", MessageKey(msg))
+				assert.Equal(t, json.Type, msg.Headers()[encoding.ContentTypeHeader])
+				assert.Equal(t, Metadata{Topic: "topic", Partition: 3, Offset: 10, Key: []byte("key"), Timestamp: ts}, msg.Metadata())
+				tags := msg.span.(*mocktracer.MockSpan).Tags()
+				assert.Equal(t, "topic", tags["topic"])
+				assert.Equal(t, int32(3), tags["partition"])
+				assert.Equal(t, int64(10), tags["offset"])
+			}
+		})
+	}
+}
+
+type otherMessage struct {
+	async.Message
+}
+
+func (om otherMessage) Metadata() interface{} {
+	return nil
+}
+
+func TestKeyFuncs_UnknownMessage(t *testing.T) {
+	assert.Empty(t, PartitionKey(otherMessage{}))
+	assert.Empty(t, MessageKey(otherMessage{}))
+}
+
 func TestMapHeader(t *testing.T) {
 	hh := []*sarama.RecordHeader{
 		&sarama.RecordHeader{
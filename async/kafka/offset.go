@@ -0,0 +1,222 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"github.com/mantzas/patron/errors"
+)
+
+// offsets tracks the delivered messages of the partitions of a generation and the offsets to be committed.
+// The offset of a partition advances only up to its first unacknowledged message, so that acknowledging
+// out of order never commits a offset past a message which has not been acknowledged. A nacked message caps
+// the offset of its partition for the rest of the generation, so the messages following it are no longer tracked.
+type offsets struct {
+	sync.Mutex
+	closed     bool
+	partitions map[string]map[int32]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	pending   []int64
+	acked     map[int64]struct{}
+	next      int64
+	committed int64
+	nacked    int64
+}
+
+func newOffsets() *offsets {
+	return &offsets{partitions: make(map[string]map[int32]*partitionOffsets)}
+}
+
+// deliver records the offset of a message of the partition, in the order of the partition.
+func (o *offsets) deliver(topic string, partition int32, offset int64) {
+	o.Lock()
+	defer o.Unlock()
+	if o.closed {
+		return
+	}
+	pp, ok := o.partitions[topic]
+	if !ok {
+		pp = make(map[int32]*partitionOffsets)
+		o.partitions[topic] = pp
+	}
+	po, ok := pp[partition]
+	if !ok {
+		po = &partitionOffsets{acked: make(map[int64]struct{}), next: -1, committed: -1, nacked: -1}
+		pp[partition] = po
+	}
+	if po.capped(offset) {
+		return
+	}
+	po.pending = append(po.pending, offset)
+}
+
+// ack marks the message as acknowledged and advances the offset to be committed past the acknowledged messages
+// at the start of the partition.
+func (o *offsets) ack(topic string, partition int32, offset int64) {
+	o.Lock()
+	defer o.Unlock()
+	if o.closed {
+		return
+	}
+	po, ok := o.partitions[topic][partition]
+	if !ok || po.capped(offset) {
+		return
+	}
+	po.acked[offset] = struct{}{}
+	po.advance()
+}
+
+// nack marks the message as not acknowledged, which caps the offset to be committed of the partition at the message,
+// so that it is consumed again after a restart or rebalance, and stops tracking the messages following it.
+func (o *offsets) nack(topic string, partition int32, offset int64) {
+	o.Lock()
+	defer o.Unlock()
+	if o.closed {
+		return
+	}
+	po, ok := o.partitions[topic][partition]
+	if !ok || po.capped(offset) {
+		return
+	}
+	po.nacked = offset
+	pending := po.pending[:0]
+	for _, p := range po.pending {
+		if p < offset {
+			pending = append(pending, p)
+		}
+	}
+	po.pending = pending
+	for a := range po.acked {
+		if a >= offset {
+			delete(po.acked, a)
+		}
+	}
+	po.advance()
+}
+
+// capped returns whether the offset is at or past a nacked message of the partition.
+func (po *partitionOffsets) capped(offset int64) bool {
+	return po.nacked >= 0 && offset >= po.nacked
+}
+
+// advance advances the offset to be committed past the acknowledged messages at the start of the partition.
+func (po *partitionOffsets) advance() {
+	for len(po.pending) > 0 {
+		head := po.pending[0]
+		if _, ok := po.acked[head]; !ok {
+			break
+		}
+		delete(po.acked, head)
+		po.pending = po.pending[1:]
+		po.next = head + 1
+	}
+}
+
+// uncommitted returns the offsets to be committed of the partitions, which have advanced since the last commit.
+func (o *offsets) uncommitted() map[string]map[int32]int64 {
+	o.Lock()
+	defer o.Unlock()
+	uu := make(map[string]map[int32]int64)
+	for topic, pp := range o.partitions {
+		for partition, po := range pp {
+			if po.next <= po.committed {
+				continue
+			}
+			if uu[topic] == nil {
+				uu[topic] = make(map[int32]int64)
+			}
+			uu[topic][partition] = po.next
+		}
+	}
+	return uu
+}
+
+// committed records the offset of the partition as committed.
+func (o *offsets) committed(topic string, partition int32, offset int64) {
+	o.Lock()
+	defer o.Unlock()
+	po, ok := o.partitions[topic][partition]
+	if !ok || offset <= po.committed {
+		return
+	}
+	po.committed = offset
+}
+
+// close stops tracking the messages, e.g. when the partitions are released after a rebalance.
+func (o *offsets) close() {
+	o.Lock()
+	defer o.Unlock()
+	o.closed = true
+}
+
+// fetchOffset returns the committed offset of the partition for the group, or the offset of the start time,
+// or the start offset, when there is none.
+func (c *consumer) fetchOffset(topic string, partition int32) (int64, error) {
+	coord, err := c.client.Coordinator(c.group)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get group coordinator")
+	}
+	req := &sarama.OffsetFetchRequest{Version: 1, ConsumerGroup: c.group}
+	req.AddPartition(topic, partition)
+	resp, err := coord.FetchOffset(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to fetch offset")
+	}
+	block := resp.GetBlock(topic, partition)
+	if block == nil {
+		return 0, errors.New("offset of partition is missing from response")
+	}
+	if block.Err != sarama.ErrNoError {
+		return 0, errors.Wrap(block.Err, "failed to fetch offset")
+	}
+	if block.Offset < 0 {
+		return c.startOffset(topic, partition)
+	}
+	return block.Offset, nil
+}
+
+// commit commits synchronously the offsets of the acknowledged messages of the generation.
+func (c *consumer) commit(g *generation) error {
+	uu := g.offsets.uncommitted()
+	if len(uu) == 0 {
+		return nil
+	}
+	coord, err := c.client.Coordinator(c.group)
+	if err != nil {
+		return errors.Wrap(err, "failed to get group coordinator")
+	}
+	req := &sarama.OffsetCommitRequest{
+		Version:                 2,
+		ConsumerGroup:           c.group,
+		ConsumerGroupGeneration: g.id,
+		ConsumerID:              c.memberID,
+		RetentionTime:           -1,
+	}
+	for topic, pp := range uu {
+		for partition, offset := range pp {
+			req.AddBlock(topic, partition, offset, 0, "")
+		}
+	}
+	resp, err := coord.CommitOffset(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to commit offsets")
+	}
+	var errs []error
+	for topic, pp := range uu {
+		for partition, offset := range pp {
+			kerr, ok := resp.Errors[topic][partition]
+			if !ok {
+				errs = append(errs, errors.Errorf("commit of partition %d of topic %s is missing from response", partition, topic))
+				continue
+			}
+			if kerr != sarama.ErrNoError {
+				errs = append(errs, c.groupError(kerr))
+				continue
+			}
+			g.offsets.committed(topic, partition, offset)
+		}
+	}
+	return errors.Aggregate(errs...)
+}
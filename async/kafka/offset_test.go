@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsets(t *testing.T) {
+	tests := []struct {
+		name      string
+		delivered []int64
+		nacked    []int64
+		acked     []int64
+		want      map[string]map[int32]int64
+	}{
+		{name: "nothing acked", delivered: []int64{1, 2, 3}, want: map[string]map[int32]int64{}},
+		{name: "in order", delivered: []int64{1, 2, 3}, acked: []int64{1, 2}, want: map[string]map[int32]int64{"topic": {0: 3}}},
+		{name: "out of order", delivered: []int64{1, 2, 3}, acked: []int64{3, 1}, want: map[string]map[int32]int64{"topic": {0: 2}}},
+		{name: "out of order completed", delivered: []int64{1, 2, 3}, acked: []int64{3, 2, 1}, want: map[string]map[int32]int64{"topic": {0: 4}}},
+		{name: "first unacked", delivered: []int64{1, 2, 3}, acked: []int64{2, 3}, want: map[string]map[int32]int64{}},
+		{name: "offset gaps", delivered: []int64{1, 5, 9}, acked: []int64{1, 5}, want: map[string]map[int32]int64{"topic": {0: 6}}},
+		{name: "nacked", delivered: []int64{1, 2, 3}, nacked: []int64{1}, want: map[string]map[int32]int64{}},
+		{name: "nacked after acked", delivered: []int64{1, 2, 3}, nacked: []int64{2}, acked: []int64{1}, want: map[string]map[int32]int64{"topic": {0: 2}}},
+		{name: "acked before nacked", delivered: []int64{1, 2, 3}, nacked: []int64{3}, acked: []int64{2, 1}, want: map[string]map[int32]int64{"topic": {0: 3}}},
+		{name: "acked past nacked", delivered: []int64{1, 2, 3}, nacked: []int64{1}, acked: []int64{2, 3}, want: map[string]map[int32]int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oo := newOffsets()
+			for _, o := range tt.delivered {
+				oo.deliver("topic", 0, o)
+			}
+			for _, o := range tt.nacked {
+				oo.nack("topic", 0, o)
+			}
+			for _, o := range tt.acked {
+				oo.ack("topic", 0, o)
+			}
+			assert.Equal(t, tt.want, oo.uncommitted())
+		})
+	}
+}
+
+func TestOffsets_NackedHead(t *testing.T) {
+	oo := newOffsets()
+	for o := int64(0); o <= 1000; o++ {
+		oo.deliver("topic", 0, o)
+	}
+	oo.nack("topic", 0, 0)
+	for o := int64(1); o <= 1000; o++ {
+		oo.ack("topic", 0, o)
+	}
+	oo.deliver("topic", 0, 1001)
+	assert.Equal(t, map[string]map[int32]int64{}, oo.uncommitted())
+	po := oo.partitions["topic"][0]
+	assert.Empty(t, po.pending)
+	assert.Empty(t, po.acked)
+}
+
+func TestOffsets_NackedBehindAcked(t *testing.T) {
+	oo := newOffsets()
+	for o := int64(0); o < 5; o++ {
+		oo.deliver("topic", 0, o)
+	}
+	oo.ack("topic", 0, 0)
+	oo.nack("topic", 0, 2)
+	oo.ack("topic", 0, 3)
+	oo.ack("topic", 0, 1)
+	assert.Equal(t, map[string]map[int32]int64{"topic": {0: 2}}, oo.uncommitted())
+}
+
+func TestOffsets_Committed(t *testing.T) {
+	oo := newOffsets()
+	oo.deliver("topic", 0, 1)
+	oo.deliver("topic", 1, 1)
+	oo.ack("topic", 0, 1)
+	oo.ack("topic", 1, 1)
+	oo.committed("topic", 0, 2)
+	assert.Equal(t, map[string]map[int32]int64{"topic": {1: 2}}, oo.uncommitted())
+	oo.deliver("topic", 0, 2)
+	oo.ack("topic", 0, 2)
+	assert.Equal(t, map[string]map[int32]int64{"topic": {0: 3, 1: 2}}, oo.uncommitted())
+}
+
+func TestOffsets_Closed(t *testing.T) {
+	oo := newOffsets()
+	oo.deliver("topic", 0, 1)
+	oo.close()
+	oo.ack("topic", 0, 1)
+	oo.deliver("topic", 0, 2)
+	assert.Equal(t, map[string]map[int32]int64{}, oo.uncommitted())
+}
@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"regexp"
 	"time"
 
 	"github.com/Shopify/sarama"
@@ -53,3 +54,164 @@ func Timeout(timeout time.Duration) OptionFunc {
 		return nil
 	}
 }
+
+// Rebalance option for setting how the partitions are assigned to the members of a consumer group.
+func Rebalance(b Balance) OptionFunc {
+	return func(c *consumer) error {
+		if c.group == "" {
+			return errors.New("rebalance applies only to group consumers")
+		}
+		if b != RangeBalance && b != RoundRobinBalance {
+			return errors.New("invalid balance provided")
+		}
+		c.balance = b
+		return nil
+	}
+}
+
+// SessionTimeout option for setting the time after which a group consumer, which has not sent a heartbeat,
+// is removed from the group.
+func SessionTimeout(timeout time.Duration) OptionFunc {
+	return func(c *consumer) error {
+		if c.group == "" {
+			return errors.New("session timeout applies only to group consumers")
+		}
+		if timeout <= 0 {
+			return errors.New("session timeout must be positive")
+		}
+		c.session = timeout
+		return nil
+	}
+}
+
+// HeartbeatInterval option for setting the interval of the heartbeats of a group consumer,
+// which has to be lower than the session timeout.
+func HeartbeatInterval(interval time.Duration) OptionFunc {
+	return func(c *consumer) error {
+		if c.group == "" {
+			return errors.New("heartbeat interval applies only to group consumers")
+		}
+		if interval <= 0 {
+			return errors.New("heartbeat interval must be positive")
+		}
+		c.heartbeat = interval
+		return nil
+	}
+}
+
+// CommitInterval option for setting how often a group consumer commits the offsets of the acknowledged messages.
+// Offsets are also committed when the partitions are released on a rebalance and when the consumer is closed.
+func CommitInterval(interval time.Duration) OptionFunc {
+	return func(c *consumer) error {
+		if c.group == "" {
+			return errors.New("commit interval applies only to group consumers")
+		}
+		if interval <= 0 {
+			return errors.New("commit interval must be positive")
+		}
+		c.cfg.Consumer.Offsets.CommitInterval = interval
+		return nil
+	}
+}
+
+// PartitionMaxInFlight option for pausing the consumption of a partition when its in-flight messages, which
+// have been delivered but not yet acknowledged, reach the max, until they have been drained to half of it.
+func PartitionMaxInFlight(max int) OptionFunc {
+	return func(c *consumer) error {
+		if max <= 0 {
+			return errors.New("partition max in-flight must be positive")
+		}
+		c.partitionInFlight = max
+		return nil
+	}
+}
+
+// StartTime option for starting the consumption of every partition from the first message with a timestamp
+// at or after the time, or from the newest offset when there is none. Group consumers start from the time only
+// when the group has not committed a offset for the partition.
+func StartTime(t time.Time) OptionFunc {
+	return func(c *consumer) error {
+		if t.IsZero() {
+			return errors.New("start time is required")
+		}
+		c.startTime = t
+		return nil
+	}
+}
+
+// StartOffsets option for starting the consumption of partitions from explicit offsets, or OffsetNewest and
+// OffsetOldest, which take precedence over the start time and offset.
+func StartOffsets(offsets map[int32]int64) OptionFunc {
+	return func(c *consumer) error {
+		if len(offsets) == 0 {
+			return errors.New("start offsets are required")
+		}
+		for partition, offset := range offsets {
+			if offset < int64(OffsetOldest) {
+				return errors.Errorf("invalid start offset %d provided for partition %d", offset, partition)
+			}
+		}
+		c.startOffsets = offsets
+		return nil
+	}
+}
+
+// Partitions option for consuming only a subset of the partitions of the topic.
+func Partitions(partitions ...int32) OptionFunc {
+	return func(c *consumer) error {
+		if len(partitions) == 0 {
+			return errors.New("partitions are required")
+		}
+		for _, partition := range partitions {
+			if partition < 0 {
+				return errors.Errorf("invalid partition %d provided", partition)
+			}
+		}
+		c.partitions = partitions
+		return nil
+	}
+}
+
+// Topics option for consuming several topics, along with the topic of the factory.
+func Topics(topics ...string) OptionFunc {
+	return func(c *consumer) error {
+		if len(topics) == 0 {
+			return errors.New("topics are required")
+		}
+		for _, topic := range topics {
+			if topic == "" {
+				return errors.New("topic is required")
+			}
+		}
+		c.topics = topics
+		return nil
+	}
+}
+
+// TopicPattern option for consuming every topic matching the regular expression, along with the topic of the factory.
+// The topics are refreshed every topic refresh interval, so that the topics created later are consumed too,
+// from the start offset.
+func TopicPattern(pattern string) OptionFunc {
+	return func(c *consumer) error {
+		if pattern == "" {
+			return errors.New("topic pattern is required")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return errors.Wrap(err, "invalid topic pattern provided")
+		}
+		c.pattern = re
+		return nil
+	}
+}
+
+// TopicRefresh option for setting how often the topics matching the topic pattern are refreshed.
+func TopicRefresh(interval time.Duration) OptionFunc {
+	return func(c *consumer) error {
+		if interval <= 0 {
+			return errors.New("topic refresh must be positive")
+		}
+		c.topicRefresh = interval
+		return nil
+	}
+}
@@ -73,3 +73,71 @@ func TestVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestPartitionMaxInFlight(t *testing.T) {
+	c := consumer{}
+	assert.Error(t, PartitionMaxInFlight(0)(&c))
+	assert.NoError(t, PartitionMaxInFlight(10)(&c))
+	assert.Equal(t, 10, c.partitionInFlight)
+}
+
+func TestStartTime(t *testing.T) {
+	c := consumer{}
+	assert.Error(t, StartTime(time.Time{})(&c))
+	assert.NoError(t, StartTime(time.Now())(&c))
+}
+
+func TestStartOffsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		offsets map[int32]int64
+		wantErr bool
+	}{
+		{name: "success", offsets: map[int32]int64{0: 10, 1: int64(OffsetOldest)}, wantErr: false},
+		{name: "failed, missing offsets", offsets: nil, wantErr: true},
+		{name: "failed, invalid offset", offsets: map[int32]int64{0: -3}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := consumer{}
+			err := StartOffsets(tt.offsets)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.offsets, c.startOffsets)
+			}
+		})
+	}
+}
+
+func TestPartitions(t *testing.T) {
+	c := consumer{}
+	assert.Error(t, Partitions()(&c))
+	assert.Error(t, Partitions(1, -1)(&c))
+	assert.NoError(t, Partitions(0, 2)(&c))
+	assert.Equal(t, []int32{0, 2}, c.partitions)
+}
+
+func TestTopics(t *testing.T) {
+	c := consumer{}
+	assert.Error(t, Topics()(&c))
+	assert.Error(t, Topics("topic", "")(&c))
+	assert.NoError(t, Topics("topic1", "topic2")(&c))
+	assert.Equal(t, []string{"topic1", "topic2"}, c.topics)
+}
+
+func TestTopicPattern(t *testing.T) {
+	c := consumer{}
+	assert.Error(t, TopicPattern("")(&c))
+	assert.Error(t, TopicPattern("orders.(")(&c))
+	assert.NoError(t, TopicPattern(`^orders\..*`)(&c))
+	assert.True(t, c.pattern.MatchString("orders.created"))
+}
+
+func TestTopicRefresh(t *testing.T) {
+	c := consumer{}
+	assert.Error(t, TopicRefresh(0)(&c))
+	assert.NoError(t, TopicRefresh(time.Second)(&c))
+	assert.Equal(t, time.Second, c.topicRefresh)
+}
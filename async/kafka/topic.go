@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/mantzas/patron/async"
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+)
+
+const defaultTopicRefresh = time.Minute
+
+// configuredTopics returns the topic and the topics provided with the Topics option.
+func (c *consumer) configuredTopics() []string {
+	if c.topic == "" {
+		return c.topics
+	}
+	return append([]string{c.topic}, c.topics...)
+}
+
+// subscription returns the sorted topics to consume, which are the topics provided and, with a topic pattern,
+// the topics of the cluster matching it, according to the refreshed metadata.
+func (c *consumer) subscription() ([]string, error) {
+	set := make(map[string]struct{})
+	for _, topic := range c.configuredTopics() {
+		set[topic] = struct{}{}
+	}
+	if c.pattern != nil {
+		err := c.client.RefreshMetadata()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to refresh metadata")
+		}
+		all, err := c.client.Topics()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get topics")
+		}
+		for _, topic := range all {
+			if c.pattern.MatchString(topic) {
+				set[topic] = struct{}{}
+			}
+		}
+	}
+	topics := make([]string, 0, len(set))
+	for topic := range set {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// watchTopics consumes the topics matching the topic pattern, which are created after the consumption started,
+// checking every topic refresh until the context is done.
+func (c *consumer) watchTopics(ctx context.Context, topics []string, chMsg chan<- async.Message, chErr chan<- error) {
+	defer close(c.done)
+	consumed := make(map[string]struct{}, len(topics))
+	for _, topic := range topics {
+		consumed[topic] = struct{}{}
+	}
+	t := time.NewTicker(c.topicRefresh)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		topics, err := c.subscription()
+		if err != nil {
+			log.Errorf("failed to refresh topics matching '%s': %v", c.pattern, err)
+			continue
+		}
+		for _, topic := range topics {
+			if _, ok := consumed[topic]; ok {
+				continue
+			}
+			err = c.consumeTopic(ctx, topic, chMsg, chErr)
+			if err != nil {
+				chErr <- err
+				return
+			}
+			consumed[topic] = struct{}{}
+			log.Infof("consuming messages for new topic '%s'", topic)
+		}
+	}
+}
+
+// sameTopics returns whether the sorted topics are the same.
+func sameTopics(tt1, tt2 []string) bool {
+	if len(tt1) != len(tt2) {
+		return false
+	}
+	for i := range tt1 {
+		if tt1[i] != tt2[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package async
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ackedOutcome  = "acked"
+	nackedOutcome = "nacked"
+	failedOutcome = "failed"
+)
+
+var (
+	processingLatency *prometheus.HistogramVec
+	messageOutcomes   *prometheus.CounterVec
+	messagesInFlight  *prometheus.GaugeVec
+)
+
+func init() {
+	processingLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: "async",
+			Name:      "processing_seconds",
+			Help:      "Processing latency of messages, or batches, classified by name",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"name"},
+	)
+	messageOutcomes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "async",
+			Name:      "messages",
+			Help:      "Messages which have been acked, nacked or failed processing, classified by name and outcome",
+		},
+		[]string{"name", "outcome"},
+	)
+	messagesInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "async",
+			Name:      "messages_in_flight",
+			Help:      "Messages being processed, classified by name",
+		},
+		[]string{"name"},
+	)
+	prometheus.MustRegister(processingLatency, messageOutcomes, messagesInFlight)
+}
+
+// trackedMessage counts the acknowledgements of a message, whether they are made by the component
+// or by the processor.
+type trackedMessage struct {
+	Message
+	name string
+}
+
+func (tm *trackedMessage) Ack() error {
+	messageOutcomes.WithLabelValues(tm.name, ackedOutcome).Inc()
+	return tm.Message.Ack()
+}
+
+func (tm *trackedMessage) Nack() error {
+	messageOutcomes.WithLabelValues(tm.name, nackedOutcome).Inc()
+	return tm.Message.Nack()
+}
+
+// track wraps the messages for counting their acknowledgements and counts them as in-flight
+// until the returned func is called.
+func (c *Component) track(mm []Message) ([]Message, func()) {
+	tt := make([]Message, len(mm))
+	for i, msg := range mm {
+		tt[i] = &trackedMessage{Message: msg, name: c.name}
+	}
+	messagesInFlight.WithLabelValues(c.name).Add(float64(len(mm)))
+	return tt, func() {
+		messagesInFlight.WithLabelValues(c.name).Sub(float64(len(mm)))
+	}
+}
+
+// observe runs the processing func recording its latency.
+func (c *Component) observe(f func() error) error {
+	start := time.Now()
+	err := f()
+	processingLatency.WithLabelValues(c.name).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// failed counts the messages, which failed processing after any retries.
+func (c *Component) failed(n int) {
+	messageOutcomes.WithLabelValues(c.name, failedOutcome).Add(float64(n))
+}
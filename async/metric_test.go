@@ -0,0 +1,55 @@
+package async
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func metricValue(t *testing.T, c prometheus.Collector) float64 {
+	ch := make(chan prometheus.Metric, 1)
+	c.Collect(ch)
+	m := dto.Metric{}
+	assert.NoError(t, (<-ch).Write(&m))
+	switch {
+	case m.Counter != nil:
+		return m.Counter.GetValue()
+	case m.Gauge != nil:
+		return m.Gauge.GetValue()
+	default:
+		return float64(m.Histogram.GetSampleCount())
+	}
+}
+
+func TestComponent_Metrics(t *testing.T) {
+	// the metrics are global, so deltas are asserted for the test to be repeatable
+	collectors := map[string]prometheus.Collector{
+		failedOutcome: messageOutcomes.WithLabelValues("metrics", failedOutcome),
+		nackedOutcome: messageOutcomes.WithLabelValues("metrics", nackedOutcome),
+		ackedOutcome:  messageOutcomes.WithLabelValues("metrics", ackedOutcome),
+		"latency":     processingLatency.WithLabelValues("metrics").(prometheus.Histogram),
+	}
+	before := make(map[string]float64, len(collectors))
+	for k, c := range collectors {
+		before[k] = metricValue(t, c)
+	}
+	proc := mockProcessor{retError: true}
+	c, err := New("metrics", proc.Process, &mockConsumerFactory{}, FailureStrategy(NackStrategy))
+	assert.NoError(t, err)
+	mm, done := c.track([]Message{&mockMessage{ctx: context.Background()}, &mockMessage{ctx: context.Background()}})
+	assert.Equal(t, 2.0, metricValue(t, messagesInFlight.WithLabelValues("metrics")))
+	ch := make(chan error, 1)
+	c.processMessage(context.Background(), mm[0], ch)
+	proc.retError = false
+	c.processMessage(context.Background(), mm[1], ch)
+	done()
+	assert.Len(t, ch, 0)
+	assert.Equal(t, 0.0, metricValue(t, messagesInFlight.WithLabelValues("metrics")))
+	want := map[string]float64{failedOutcome: 1, nackedOutcome: 1, ackedOutcome: 1, "latency": 2}
+	for k, c := range collectors {
+		assert.Equal(t, want[k], metricValue(t, c)-before[k], k)
+	}
+}
@@ -0,0 +1,109 @@
+package async
+
+import (
+	"context"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+)
+
+// MiddlewareFunc definition of a function which wraps a processor, in order to handle cross-cutting concerns.
+type MiddlewareFunc func(ProcessorFunc) ProcessorFunc
+
+// FilterFunc definition of a function which reports whether a message should be processed.
+type FilterFunc func(Message) bool
+
+// chain wraps the processor with the middlewares, with the first middleware being the outermost.
+func chain(p ProcessorFunc, mm ...MiddlewareFunc) ProcessorFunc {
+	for i := len(mm) - 1; i >= 0; i-- {
+		p = mm[i](p)
+	}
+	return p
+}
+
+// LoggingMiddleware logs the outcome and duration of processing every message.
+func LoggingMiddleware() MiddlewareFunc {
+	return func(next ProcessorFunc) ProcessorFunc {
+		return func(msg Message) error {
+			start := time.Now()
+			err := next(msg)
+			if err != nil {
+				log.FromContext(msg.Context()).Errorf("message failed after %v: %v", time.Since(start), err)
+				return err
+			}
+			log.FromContext(msg.Context()).Debugf("message processed in %v", time.Since(start))
+			return nil
+		}
+	}
+}
+
+// RecoveryMiddleware converts a panic of the processor into a error, which is handled by the failure strategy.
+// The component always recovers from panics, the middleware allows the panic to be handled by the outer middlewares.
+func RecoveryMiddleware() MiddlewareFunc {
+	return func(next ProcessorFunc) ProcessorFunc {
+		return func(msg Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = errors.FromPanic(r)
+				}
+			}()
+			return next(msg)
+		}
+	}
+}
+
+// TimeoutMiddleware sets a timeout on the context of every message, which processors have to honor.
+func TimeoutMiddleware(d time.Duration) MiddlewareFunc {
+	return func(next ProcessorFunc) ProcessorFunc {
+		return func(msg Message) error {
+			ctx, cnl := context.WithTimeout(msg.Context(), d)
+			defer cnl()
+			return next(&contextMessage{Message: msg, ctx: ctx})
+		}
+	}
+}
+
+type contextMessage struct {
+	Message
+	ctx context.Context
+}
+
+func (cm *contextMessage) Context() context.Context {
+	return cm.ctx
+}
+
+// FilterMiddleware skips the messages which do not pass the filter. Skipped messages are acknowledged.
+func FilterMiddleware(f FilterFunc) MiddlewareFunc {
+	return func(next ProcessorFunc) ProcessorFunc {
+		return func(msg Message) error {
+			if !f(msg) {
+				log.FromContext(msg.Context()).Debug("message skipped by filter")
+				return nil
+			}
+			return next(msg)
+		}
+	}
+}
+
+// HeaderFilter returns a filter which passes the messages with a header having one of the values.
+func HeaderFilter(key string, values ...string) FilterFunc {
+	return func(msg Message) bool {
+		v, ok := msg.Headers()[key]
+		if !ok {
+			return false
+		}
+		for _, value := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// DedupMiddleware skips the messages with a ID, as extracted by the ID func, which has already been processed
+// successfully according to the dedup store. Skipped messages are acknowledged. Messages without a ID are always processed.
+func DedupMiddleware(id IDFunc, store DedupStore) MiddlewareFunc {
+	return dedup(id, store, func() {})
+}
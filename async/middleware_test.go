@@ -0,0 +1,109 @@
+package async
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	mw := func(name string) MiddlewareFunc {
+		return func(next ProcessorFunc) ProcessorFunc {
+			return func(msg Message) error {
+				calls = append(calls, name)
+				return next(msg)
+			}
+		}
+	}
+	p := chain(func(Message) error {
+		calls = append(calls, "proc")
+		return nil
+	}, mw("first"), mw("second"))
+	assert.NoError(t, p(&mockMessage{ctx: context.Background()}))
+	assert.Equal(t, []string{"first", "second", "proc"}, calls)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	msg := &mockMessage{ctx: context.Background()}
+	assert.NoError(t, LoggingMiddleware()(func(Message) error { return nil })(msg))
+	assert.Error(t, LoggingMiddleware()(func(Message) error { return errors.New("PROC ERROR") })(msg))
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	err := RecoveryMiddleware()(func(Message) error { panic("PANIC") })(&mockMessage{ctx: context.Background()})
+	assert.Error(t, err)
+	assert.IsType(t, &errors.Panic{}, err)
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	err := TimeoutMiddleware(time.Millisecond)(func(msg Message) error {
+		<-msg.Context().Done()
+		return msg.Context().Err()
+	})(&mockMessage{ctx: context.Background()})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestFilterMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantProc bool
+	}{
+		{name: "passes", headers: map[string]string{"type": "order"}, wantProc: true},
+		{name: "passes other value", headers: map[string]string{"type": "refund"}, wantProc: true},
+		{name: "skipped", headers: map[string]string{"type": "invoice"}, wantProc: false},
+		{name: "skipped missing header", headers: nil, wantProc: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed := false
+			p := FilterMiddleware(HeaderFilter("type", "order", "refund"))(func(Message) error {
+				processed = true
+				return nil
+			})
+			assert.NoError(t, p(&mockMessage{ctx: context.Background(), headers: tt.headers}))
+			assert.Equal(t, tt.wantProc, processed)
+		})
+	}
+}
+
+func TestDedupMiddleware(t *testing.T) {
+	calls := 0
+	fail := true
+	store, err := NewMemoryStore(10, 20*time.Millisecond)
+	assert.NoError(t, err)
+	p := DedupMiddleware(HeaderID("id"), store)(func(Message) error {
+		calls++
+		if fail {
+			fail = false
+			return errors.New("PROC ERROR")
+		}
+		return nil
+	})
+	msg := &mockMessage{ctx: context.Background(), headers: map[string]string{"id": "1"}}
+	assert.Error(t, p(msg))
+	assert.NoError(t, p(msg))
+	assert.NoError(t, p(msg))
+	assert.Equal(t, 2, calls)
+	assert.NoError(t, p(&mockMessage{ctx: context.Background()}))
+	assert.NoError(t, p(&mockMessage{ctx: context.Background()}))
+	assert.Equal(t, 4, calls)
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, p(msg))
+	assert.Equal(t, 5, calls)
+}
+
+func TestNew_Middlewares(t *testing.T) {
+	skip := FilterMiddleware(func(Message) bool { return false })
+	proc := mockProcessor{retError: true}
+	c, err := New("test", proc.Process, &mockConsumerFactory{}, Middlewares(LoggingMiddleware(), skip))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, c.Info()["middlewares"])
+	assert.NoError(t, c.proc(&mockMessage{ctx: context.Background()}))
+	_, err = NewBatch("test", func([]Message) error { return nil }, &mockConsumerFactory{}, Middlewares(skip))
+	assert.Error(t, err)
+}
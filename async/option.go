@@ -5,6 +5,7 @@ import (
 
 	"github.com/mantzas/patron/errors"
 	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/reliability/retry"
 )
 
 // FailStrategy type definition.
@@ -17,6 +18,10 @@ const (
 	NackStrategy FailStrategy = 1
 	// AckStrategy acknowledges message and continues.
 	AckStrategy FailStrategy = 2
+	// DeadLetterStrategy publishes the message to a retry tier or dead letter destination, acknowledges it and continues.
+	// If publishing fails the message is not acknowledged and the application exits.
+	// It is set with the DeadLetter option.
+	DeadLetterStrategy FailStrategy = 3
 )
 
 func (fs FailStrategy) String() string {
@@ -27,6 +32,8 @@ func (fs FailStrategy) String() string {
 		return "NackStrategy"
 	case AckStrategy:
 		return "AckStrategy"
+	case DeadLetterStrategy:
+		return "DeadLetterStrategy"
 	default:
 		return "N/A"
 	}
@@ -47,10 +54,12 @@ func FailureStrategy(fs FailStrategy) OptionFunc {
 	}
 }
 
-// ConsumerRetry set's the parameters for the retry policy of the consumer.
+// ConsumerRetry set's the parameters for the retry policy of the consumer, which is recreated when it fails
+// after waiting the retry wait, or the backoff when the ConsumerBackoff option is set.
+// Use retry.Infinite to retry until the component is stopped.
 func ConsumerRetry(retries int, retryWait time.Duration) OptionFunc {
 	return func(c *Component) error {
-		if retries < 0 {
+		if retries < 0 && retries != retry.Infinite {
 			return errors.New("invalid retries provided")
 		}
 
@@ -64,3 +73,191 @@ func ConsumerRetry(retries int, retryWait time.Duration) OptionFunc {
 		return nil
 	}
 }
+
+// ConsumerBackoff option for waiting with backoff between the retries of the consumer, instead of a fixed wait.
+// When reset is set and the consumer has been consuming for at least as long before failing, the retries
+// and backoff start over.
+func ConsumerBackoff(b retry.Backoff, reset time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if err := b.Validate(); err != nil {
+			return errors.Wrap(err, "invalid consumer backoff provided")
+		}
+		if reset < 0 {
+			return errors.New("invalid consumer backoff reset provided")
+		}
+		c.backoff = &b
+		c.backoffReset = reset
+		log.Info("consumer backoff set")
+		return nil
+	}
+}
+
+// ProcessorRetry option for retrying the processing of a failed message, or batch, with backoff before
+// the failure strategy is applied. By default every error is retried except panics, use RetryIf to change it.
+func ProcessorRetry(retries int, b retry.Backoff) OptionFunc {
+	return func(c *Component) error {
+		if retries < 0 {
+			return errors.New("invalid processor retries provided")
+		}
+		if err := b.Validate(); err != nil {
+			return errors.Wrap(err, "invalid processor backoff provided")
+		}
+		c.procRetries = retries
+		c.procBackoff = b
+		log.Info("processor retry set")
+		return nil
+	}
+}
+
+// RetryIf option for setting which processing errors are retried.
+func RetryIf(f RetryableFunc) OptionFunc {
+	return func(c *Component) error {
+		if f == nil {
+			return errors.New("retryable func is required")
+		}
+		c.retryable = f
+		log.Info("retryable func set")
+		return nil
+	}
+}
+
+// Middlewares option for wrapping the processor with middlewares, with the first middleware being the outermost.
+func Middlewares(mm ...MiddlewareFunc) OptionFunc {
+	return func(c *Component) error {
+		if len(mm) == 0 {
+			return errors.New("middlewares are required")
+		}
+		for _, m := range mm {
+			if m == nil {
+				return errors.New("middleware is nil")
+			}
+		}
+		c.mw = append(c.mw, mm...)
+		log.Info("middlewares set")
+		return nil
+	}
+}
+
+// Idempotent option for skipping duplicate messages, which are identified by the ID func and have already been
+// processed successfully according to the dedup store. Duplicates are acknowledged without being processed.
+// Idempotency is applied before any middlewares.
+func Idempotent(id IDFunc, store DedupStore) OptionFunc {
+	return func(c *Component) error {
+		if id == nil {
+			return errors.New("id func is required")
+		}
+		if store == nil {
+			return errors.New("dedup store is required")
+		}
+		c.dedup = dedup(id, store, func() {
+			duplicates.WithLabelValues(c.name).Inc()
+		})
+		log.Info("idempotent processing set")
+		return nil
+	}
+}
+
+// Workers option for processing messages in a fixed pool of workers instead of a goroutine per message.
+func Workers(n int) OptionFunc {
+	return func(c *Component) error {
+		if n <= 0 {
+			return errors.New("workers must be positive")
+		}
+		c.workers = n
+		log.Info("workers set")
+		return nil
+	}
+}
+
+// MaxInFlight option for limiting the messages which are queued or processed at any time.
+// When the limit is reached no more messages are received from the consumer until a message completes.
+// Messages of batches count individually, so the limit has to be at least the batch size.
+func MaxInFlight(n int) OptionFunc {
+	return func(c *Component) error {
+		if n <= 0 {
+			return errors.New("max in-flight must be positive")
+		}
+		c.maxInFlight = n
+		log.Info("max in-flight set")
+		return nil
+	}
+}
+
+// OrderedBy option for processing messages with the same key, as extracted by the key func, sequentially
+// in the order they were received, while messages with different keys are processed concurrently.
+// Messages are assigned to the workers by their key, with the workers defaulting to the number of CPUs.
+func OrderedBy(kf KeyFunc) OptionFunc {
+	return func(c *Component) error {
+		if kf == nil {
+			return errors.New("key func is required")
+		}
+		c.key = kf
+		log.Info("ordered processing set")
+		return nil
+	}
+}
+
+// BatchSize option for setting the max number of messages of a batch.
+func BatchSize(n int) OptionFunc {
+	return func(c *Component) error {
+		if n <= 0 {
+			return errors.New("batch size must be positive")
+		}
+		c.batchSize = n
+		log.Info("batch size set")
+		return nil
+	}
+}
+
+// BatchLinger option for setting the max time to wait for a batch to fill up after its first message.
+func BatchLinger(d time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if d <= 0 {
+			return errors.New("batch linger must be positive")
+		}
+		c.batchLinger = d
+		log.Info("batch linger set")
+		return nil
+	}
+}
+
+// BatchAckMode option for setting how the messages of a batch are acknowledged.
+func BatchAckMode(am AckMode) OptionFunc {
+	return func(c *Component) error {
+		if am != BatchAck && am != MessageAck {
+			return errors.New("invalid ack mode provided")
+		}
+		c.batchAck = am
+		log.Info("batch ack mode set")
+		return nil
+	}
+}
+
+// DeadLetter option for setting the dead letter failure strategy, which publishes failed messages
+// through the publisher to the dead letter destination. With retry tiers, failed messages are first published
+// to the destination of each tier, in order, and are not processed before the delay of the tier has passed.
+// Consuming the retry tiers with an async component with the same dead letter option completes the cycle.
+// A message consumed from a retry tier blocks its worker, and its slot of the max in-flight messages, until the delay
+// has passed, so the retry tiers are best consumed by components of their own.
+func DeadLetter(p DeadLetterPublisher, dest string, tiers ...RetryTier) OptionFunc {
+	return func(c *Component) error {
+		if p == nil {
+			return errors.New("dead letter publisher is required")
+		}
+		if dest == "" {
+			return errors.New("dead letter destination is required")
+		}
+		for _, t := range tiers {
+			if t.Destination == "" {
+				return errors.New("retry tier destination is required")
+			}
+			if t.Delay < 0 {
+				return errors.New("invalid retry tier delay provided")
+			}
+		}
+		c.failStrategy = DeadLetterStrategy
+		c.dl = &deadLetter{pub: p, dest: dest, tiers: tiers}
+		log.Info("dead letter strategy set")
+		return nil
+	}
+}
@@ -1,9 +1,11 @@
 package async
 
 import (
+	"runtime"
 	"testing"
 	"time"
 
+	"github.com/mantzas/patron/reliability/retry"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,6 +18,7 @@ func TestFailStrategy_String(t *testing.T) {
 		{name: "NackExitStrategy", fs: NackExitStrategy, want: "NackExitStrategy"},
 		{name: "NackStrategy", fs: NackStrategy, want: "NackStrategy"},
 		{name: "AckStrategy", fs: AckStrategy, want: "AckStrategy"},
+		{name: "DeadLetterStrategy", fs: DeadLetterStrategy, want: "DeadLetterStrategy"},
 		{name: "Not mapped", fs: -1, want: "N/A"},
 	}
 	for _, tt := range tests {
@@ -37,7 +40,8 @@ func TestFailureStrategy(t *testing.T) {
 	}{
 		{name: "success", args: args{fs: NackExitStrategy}, wantErr: false},
 		{name: "invalid strategy (lower)", args: args{fs: -1}, wantErr: true},
-		{name: "invalid strategy (higher)", args: args{fs: 3}, wantErr: true},
+		{name: "invalid strategy (higher)", args: args{fs: 4}, wantErr: true},
+		{name: "dead letter strategy without option", args: args{fs: DeadLetterStrategy}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -65,7 +69,8 @@ func TestConsumerRetry(t *testing.T) {
 		wantErr bool
 	}{
 		{name: "success", args: args{retries: 3, retryWait: time.Second}, wantErr: false},
-		{name: "invalid retries", args: args{retries: -1, retryWait: time.Second}, wantErr: true},
+		{name: "infinite retries", args: args{retries: retry.Infinite, retryWait: time.Second}, wantErr: false},
+		{name: "invalid retries", args: args{retries: -2, retryWait: time.Second}, wantErr: true},
 		{name: "invalid retry wait", args: args{retries: 3, retryWait: -1}, wantErr: true},
 	}
 	for _, tt := range tests {
@@ -81,3 +86,222 @@ func TestConsumerRetry(t *testing.T) {
 		})
 	}
 }
+
+func TestConsumerBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       retry.Backoff
+		reset   time.Duration
+		wantErr bool
+	}{
+		{name: "success", b: retry.Backoff{Initial: time.Second, Max: time.Minute, Jitter: 0.2}, reset: time.Minute, wantErr: false},
+		{name: "invalid backoff", b: retry.Backoff{Initial: -1}, wantErr: true},
+		{name: "invalid reset", b: retry.Backoff{Initial: time.Second}, reset: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := ConsumerBackoff(tt.b, tt.reset)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, &tt.b, c.backoff)
+				assert.Equal(t, tt.reset, c.backoffReset)
+			}
+		})
+	}
+}
+
+func TestProcessorRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     OptionFunc
+		wantErr bool
+	}{
+		{name: "processor retry", opt: ProcessorRetry(3, retry.Backoff{Initial: time.Second, Max: time.Minute, Jitter: 0.2}), wantErr: false},
+		{name: "invalid retries", opt: ProcessorRetry(-1, retry.Backoff{}), wantErr: true},
+		{name: "invalid backoff", opt: ProcessorRetry(3, retry.Backoff{Jitter: 2}), wantErr: true},
+		{name: "retry if", opt: RetryIf(func(error) bool { return true }), wantErr: false},
+		{name: "missing retryable func", opt: RetryIf(nil), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := tt.opt(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMiddlewares(t *testing.T) {
+	tests := []struct {
+		name    string
+		mm      []MiddlewareFunc
+		wantErr bool
+	}{
+		{name: "success", mm: []MiddlewareFunc{LoggingMiddleware(), RecoveryMiddleware()}, wantErr: false},
+		{name: "missing middlewares", mm: nil, wantErr: true},
+		{name: "nil middleware", mm: []MiddlewareFunc{nil}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := Middlewares(tt.mm...)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, c.mw, 2)
+			}
+		})
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	store, err := NewMemoryStore(10, time.Minute)
+	assert.NoError(t, err)
+	tests := []struct {
+		name    string
+		id      IDFunc
+		store   DedupStore
+		wantErr bool
+	}{
+		{name: "success", id: HeaderID("id"), store: store, wantErr: false},
+		{name: "missing id func", id: nil, store: store, wantErr: true},
+		{name: "missing store", id: HeaderID("id"), store: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := Idempotent(tt.id, tt.store)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, c.dedup)
+			}
+		})
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		wantErr bool
+	}{
+		{name: "success", workers: 5, wantErr: false},
+		{name: "invalid workers", workers: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := Workers(tt.workers)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.workers, c.workers)
+			}
+		})
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		wantErr bool
+	}{
+		{name: "success", max: 100, wantErr: false},
+		{name: "invalid max in-flight", max: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := MaxInFlight(tt.max)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.max, c.maxInFlight)
+			}
+		})
+	}
+}
+
+func TestOrderedBy(t *testing.T) {
+	c := Component{}
+	assert.Error(t, OrderedBy(nil)(&c))
+	assert.NoError(t, OrderedBy(func(Message) string { return "" })(&c))
+	assert.NotNil(t, c.key)
+
+	proc := mockProcessor{}
+	cmp, err := New("test", proc.Process, &mockConsumerFactory{}, OrderedBy(func(Message) string { return "" }))
+	assert.NoError(t, err)
+	assert.Equal(t, runtime.NumCPU(), cmp.Info()["workers"])
+	assert.Equal(t, true, cmp.Info()["ordered"])
+}
+
+func TestBatchOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     OptionFunc
+		wantErr bool
+	}{
+		{name: "batch size", opt: BatchSize(10), wantErr: false},
+		{name: "invalid batch size", opt: BatchSize(0), wantErr: true},
+		{name: "batch linger", opt: BatchLinger(time.Second), wantErr: false},
+		{name: "invalid batch linger", opt: BatchLinger(0), wantErr: true},
+		{name: "batch ack mode", opt: BatchAckMode(MessageAck), wantErr: false},
+		{name: "invalid batch ack mode", opt: BatchAckMode(3), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := tt.opt(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	type args struct {
+		p     DeadLetterPublisher
+		dest  string
+		tiers []RetryTier
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{name: "success", args: args{p: &mockPublisher{}, dest: "dlq", tiers: []RetryTier{{Destination: "retry-5s", Delay: 5 * time.Second}}}, wantErr: false},
+		{name: "missing publisher", args: args{p: nil, dest: "dlq"}, wantErr: true},
+		{name: "missing destination", args: args{p: &mockPublisher{}, dest: ""}, wantErr: true},
+		{name: "missing tier destination", args: args{p: &mockPublisher{}, dest: "dlq", tiers: []RetryTier{{Delay: time.Second}}}, wantErr: true},
+		{name: "invalid tier delay", args: args{p: &mockPublisher{}, dest: "dlq", tiers: []RetryTier{{Destination: "retry", Delay: -1}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := DeadLetter(tt.args.p, tt.args.dest, tt.args.tiers...)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, DeadLetterStrategy, c.failStrategy)
+				assert.Equal(t, "dlq", c.dl.dest)
+			}
+		})
+	}
+}
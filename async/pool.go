@@ -0,0 +1,181 @@
+package async
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	queueDepth        *prometheus.GaugeVec
+	workerUtilization *prometheus.GaugeVec
+)
+
+func init() {
+	queueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "async",
+			Name:      "queue_depth",
+			Help:      "Messages waiting for a worker, classified by name",
+		},
+		[]string{"name"},
+	)
+	workerUtilization = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "async",
+			Name:      "worker_utilization",
+			Help:      "Ratio of busy workers, classified by name",
+		},
+		[]string{"name"},
+	)
+	prometheus.MustRegister(queueDepth, workerUtilization)
+}
+
+// KeyFunc definition of a function which extracts the key of a message.
+// Messages with the same key are processed sequentially in the order they were received.
+type KeyFunc func(Message) string
+
+// pool processes messages, one at a time or in batches, either in a goroutine each or, when workers are set,
+// in a fixed number of workers, while applying back-pressure to the consumer when the max in-flight is reached.
+// When a key func is set every worker has its own queue and messages are assigned to a worker by their key,
+// so that messages with the same key are processed in order.
+type pool struct {
+	name    string
+	workers int
+	process func([]Message)
+	key     KeyFunc
+	queues  []chan []Message
+	sem     chan struct{}
+	mu      sync.Mutex
+	busy    int
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+func newPool(ctx context.Context, name string, workers, maxInFlight int, key KeyFunc, process func([]Message)) *pool {
+	p := &pool{name: name, workers: workers, key: key, process: process}
+	if maxInFlight > 0 {
+		p.sem = make(chan struct{}, maxInFlight)
+	}
+	if workers == 0 {
+		return p
+	}
+	size := workers
+	if maxInFlight > 0 {
+		size = maxInFlight
+	}
+	queues := 1
+	if key != nil {
+		queues = workers
+	}
+	p.queues = make([]chan []Message, queues)
+	for i := range p.queues {
+		p.queues[i] = make(chan []Message, size)
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work(ctx, p.queues[i%queues])
+	}
+	return p
+}
+
+// submit hands over the messages for processing, blocking while the max in-flight messages are reached
+// or the queue of the messages is full. The in-flight messages are counted per message, also for batches,
+// which is safe since messages are submitted by a single goroutine.
+func (p *pool) submit(ctx context.Context, mm []Message) error {
+	if p.sem != nil {
+		for i := range mm {
+			select {
+			case p.sem <- struct{}{}:
+			case <-ctx.Done():
+				p.release(i)
+				return ctx.Err()
+			}
+		}
+	}
+	if p.queues == nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.run(mm)
+		}()
+		return nil
+	}
+	select {
+	case p.queue(mm[0]) <- mm:
+		p.setQueueDepth()
+		return nil
+	case <-ctx.Done():
+		p.release(len(mm))
+		return ctx.Err()
+	}
+}
+
+// queue returns the queue of the message, which is determined by the hash of its key.
+func (p *pool) queue(msg Message) chan []Message {
+	if len(p.queues) == 1 {
+		return p.queues[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(p.key(msg)))
+	return p.queues[h.Sum32()%uint32(len(p.queues))]
+}
+
+// close stops accepting messages and waits for the messages in process to complete.
+// Queued messages which have not been picked up by a worker are dropped once the context is done.
+func (p *pool) close() {
+	p.once.Do(func() {
+		for _, q := range p.queues {
+			close(q)
+		}
+	})
+	p.wg.Wait()
+}
+
+func (p *pool) work(ctx context.Context, queue <-chan []Message) {
+	defer p.wg.Done()
+	for mm := range queue {
+		p.setQueueDepth()
+		if ctx.Err() != nil {
+			p.release(len(mm))
+			continue
+		}
+		p.setBusy(1)
+		p.run(mm)
+		p.setBusy(-1)
+	}
+}
+
+func (p *pool) run(mm []Message) {
+	defer p.release(len(mm))
+	p.process(mm)
+}
+
+// release releases the in-flight slots of n messages.
+func (p *pool) release(n int) {
+	if p.sem == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		<-p.sem
+	}
+}
+
+func (p *pool) setQueueDepth() {
+	depth := 0
+	for _, q := range p.queues {
+		depth += len(q)
+	}
+	queueDepth.WithLabelValues(p.name).Set(float64(depth))
+}
+
+func (p *pool) setBusy(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.busy += delta
+	workerUtilization.WithLabelValues(p.name).Set(float64(p.busy) / float64(p.workers))
+}
@@ -0,0 +1,142 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type concurrencyRecorder struct {
+	sync.Mutex
+	current   int
+	max       int
+	processed int
+	release   chan struct{}
+}
+
+func (cr *concurrencyRecorder) process(mm []Message) {
+	cr.Lock()
+	cr.current++
+	if cr.current > cr.max {
+		cr.max = cr.current
+	}
+	cr.Unlock()
+	<-cr.release
+	cr.Lock()
+	cr.current--
+	cr.processed++
+	cr.Unlock()
+}
+
+func (cr *concurrencyRecorder) stats() (int, int) {
+	cr.Lock()
+	defer cr.Unlock()
+	return cr.max, cr.processed
+}
+
+func TestPool(t *testing.T) {
+	tests := []struct {
+		name        string
+		workers     int
+		maxInFlight int
+		wantMax     int
+	}{
+		{"goroutine per message", 0, 0, 10},
+		{"max in-flight", 0, 3, 3},
+		{"workers", 2, 0, 2},
+		{"workers and max in-flight", 4, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &concurrencyRecorder{release: make(chan struct{})}
+			ctx := context.Background()
+			p := newPool(ctx, "test", tt.workers, tt.maxInFlight, nil, cr.process)
+			done := make(chan struct{})
+			go func() {
+				for i := 0; i < 10; i++ {
+					assert.NoError(t, p.submit(ctx, []Message{&mockMessage{ctx: ctx}}))
+				}
+				close(done)
+			}()
+			time.Sleep(20 * time.Millisecond)
+			max, _ := cr.stats()
+			assert.Equal(t, tt.wantMax, max)
+			close(cr.release)
+			<-done
+			p.close()
+			max, processed := cr.stats()
+			assert.Equal(t, tt.wantMax, max)
+			assert.Equal(t, 10, processed)
+		})
+	}
+}
+
+func TestPool_submit_BackPressureCanceled(t *testing.T) {
+	cr := &concurrencyRecorder{release: make(chan struct{})}
+	ctx, cnl := context.WithCancel(context.Background())
+	p := newPool(ctx, "test", 1, 1, nil, cr.process)
+	assert.NoError(t, p.submit(ctx, []Message{&mockMessage{ctx: ctx}}))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cnl()
+	}()
+	assert.Error(t, p.submit(ctx, []Message{&mockMessage{ctx: ctx}}))
+	close(cr.release)
+	p.close()
+	_, processed := cr.stats()
+	assert.Equal(t, 1, processed)
+}
+
+func TestPool_submit_MaxInFlightMessages(t *testing.T) {
+	cr := &concurrencyRecorder{release: make(chan struct{})}
+	ctx, cnl := context.WithCancel(context.Background())
+	p := newPool(ctx, "test", 2, 3, nil, cr.process)
+	assert.NoError(t, p.submit(ctx, []Message{&mockMessage{ctx: ctx}, &mockMessage{ctx: ctx}}))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cnl()
+	}()
+	// the second batch exceeds the in-flight messages, although only one batch is in-flight
+	assert.Error(t, p.submit(ctx, []Message{&mockMessage{ctx: ctx}, &mockMessage{ctx: ctx}}))
+	assert.Len(t, p.sem, 2)
+	close(cr.release)
+	p.close()
+	assert.Len(t, p.sem, 0)
+}
+
+type keyedMessage struct {
+	mockMessage
+	key string
+	seq int
+}
+
+func TestPool_Ordered(t *testing.T) {
+	var mu sync.Mutex
+	seqs := make(map[string][]int)
+	process := func(mm []Message) {
+		km := mm[0].(*keyedMessage)
+		time.Sleep(time.Duration(km.seq%3) * time.Millisecond)
+		mu.Lock()
+		seqs[km.key] = append(seqs[km.key], km.seq)
+		mu.Unlock()
+	}
+	key := func(msg Message) string {
+		return msg.(*keyedMessage).key
+	}
+	ctx := context.Background()
+	p := newPool(ctx, "test", 4, 8, key, process)
+	kk := []string{"a", "b", "c", "d", "e"}
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, p.submit(ctx, []Message{&keyedMessage{key: kk[i%len(kk)], seq: i}}))
+	}
+	p.close()
+	for _, k := range kk {
+		assert.Len(t, seqs[k], 10)
+		for i := 1; i < len(seqs[k]); i++ {
+			assert.True(t, seqs[k][i-1] < seqs[k][i])
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package async
+
+import (
+	"context"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/reliability/retry"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var processorRetries *prometheus.CounterVec
+
+func init() {
+	processorRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "async",
+			Name:      "processor_retries",
+			Help:      "Processor retries, classified by name",
+		},
+		[]string{"name"},
+	)
+	prometheus.MustRegister(processorRetries)
+}
+
+// errRetryCanceled is returned when the context is done while waiting to retry, in which case
+// the messages have already been nacked.
+var errRetryCanceled = errors.New("processing retry canceled")
+
+// RetryableFunc definition of a function which reports whether a processing error is transient
+// and the processing should be retried.
+type RetryableFunc func(error) bool
+
+// retryable is the default retryable func, which retries every error except panics.
+func retryable(err error) bool {
+	_, ok := err.(*errors.Panic)
+	return !ok
+}
+
+// processWithRetry runs the processing function and, when a retryable error is returned, retries it
+// with backoff until it succeeds or the retries are exhausted. The attempts are recorded on the spans of the messages.
+// If the context is done while waiting to retry, the messages are nacked and errRetryCanceled is returned.
+func (c *Component) processWithRetry(ctx context.Context, mm []Message, f func() error) error {
+	err := f()
+	if c.procRetries == 0 {
+		return err
+	}
+	attempt := 1
+	for ; err != nil && attempt <= c.procRetries && c.retryable(err); attempt++ {
+		wait := c.procBackoff.Wait(attempt - 1)
+		log.FromContext(mm[0].Context()).Warnf("failed to process, retry %d/%d with %v wait: %v", attempt, c.procRetries, wait, err)
+		processorRetries.WithLabelValues(c.name).Inc()
+		for _, msg := range mm {
+			sp := opentracing.SpanFromContext(msg.Context())
+			if sp != nil {
+				sp.LogKV("event", "retry", "attempt", attempt, "wait", wait.String(), "message", err.Error())
+			}
+		}
+		if retry.Sleep(ctx, wait) != nil {
+			for _, msg := range mm {
+				if nErr := msg.Nack(); nErr != nil {
+					log.FromContext(msg.Context()).Errorf("failed to NACK message: %v", nErr)
+				}
+			}
+			return errRetryCanceled
+		}
+		err = f()
+	}
+	for _, msg := range mm {
+		sp := opentracing.SpanFromContext(msg.Context())
+		if sp != nil {
+			sp.SetTag("attempts", attempt)
+		}
+	}
+	return err
+}
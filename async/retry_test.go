@@ -0,0 +1,98 @@
+package async
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/reliability/retry"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("TRANSIENT")
+
+func TestComponent_processWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		retries      int
+		failures     int
+		err          error
+		retryIf      RetryableFunc
+		wantCalls    int
+		wantAttempts interface{}
+		wantErr      bool
+	}{
+		{name: "no retries", retries: 0, failures: 1, err: errTransient, wantCalls: 1, wantAttempts: nil, wantErr: true},
+		{name: "success after retry", retries: 3, failures: 2, err: errTransient, wantCalls: 3, wantAttempts: 3, wantErr: false},
+		{name: "retries exhausted", retries: 2, failures: 5, err: errTransient, wantCalls: 3, wantAttempts: 3, wantErr: true},
+		{name: "panic not retried", retries: 2, failures: 5, err: errors.FromPanic("PANIC"), wantCalls: 1, wantAttempts: 1, wantErr: true},
+		{name: "not retryable", retries: 2, failures: 5, err: errTransient, retryIf: func(error) bool { return false }, wantCalls: 1, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oo := []OptionFunc{ProcessorRetry(tt.retries, retry.Backoff{Initial: time.Millisecond})}
+			if tt.retryIf != nil {
+				oo = append(oo, RetryIf(tt.retryIf))
+			}
+			proc := mockProcessor{}
+			c, err := New("test", proc.Process, &mockConsumerFactory{}, oo...)
+			assert.NoError(t, err)
+			mtr := mocktracer.New()
+			sp := mtr.StartSpan("test")
+			msg := &recordingMessage{mockMessage: mockMessage{ctx: opentracing.ContextWithSpan(context.Background(), sp)}}
+			calls := 0
+			err = c.processWithRetry(context.Background(), []Message{msg}, func() error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			sp.Finish()
+			assert.Equal(t, tt.wantAttempts, mtr.FinishedSpans()[0].Tag("attempts"))
+		})
+	}
+}
+
+func TestComponent_processWithRetry_Canceled(t *testing.T) {
+	proc := mockProcessor{}
+	c, err := New("test", proc.Process, &mockConsumerFactory{}, ProcessorRetry(1, retry.Backoff{Initial: time.Minute}))
+	assert.NoError(t, err)
+	ctx, cnl := context.WithCancel(context.Background())
+	cnl()
+	msg := &recordingMessage{mockMessage: mockMessage{ctx: context.Background()}}
+	err = c.processWithRetry(ctx, []Message{msg}, func() error { return errTransient })
+	assert.Equal(t, errRetryCanceled, err)
+	_, nacked := msg.state()
+	assert.True(t, nacked)
+}
+
+func TestComponent_processMessage_Retry(t *testing.T) {
+	calls := 0
+	proc := func(Message) error {
+		calls++
+		if calls == 1 {
+			return errTransient
+		}
+		return nil
+	}
+	c, err := New("test", proc, &mockConsumerFactory{}, ProcessorRetry(1, retry.Backoff{Initial: time.Millisecond}))
+	assert.NoError(t, err)
+	msg := &recordingMessage{mockMessage: mockMessage{ctx: context.Background()}}
+	ch := make(chan error, 1)
+	c.processMessage(context.Background(), msg, ch)
+	acked, nacked := msg.state()
+	assert.True(t, acked)
+	assert.False(t, nacked)
+	assert.Len(t, ch, 0)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,46 @@
+package patron
+
+import (
+	"github.com/mantzas/patron/config"
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+)
+
+// serviceConfig holds the configuration of the service which is provided via environment variables
+// and optionally a configuration file.
+type serviceConfig struct {
+	LogLevel           string  `config:"log_level" env:"PATRON_LOG_LEVEL" default:"info"`
+	JaegerAgent        string  `config:"jaeger-agent" env:"PATRON_JAEGER_AGENT" default:"0.0.0.0:6831"`
+	JaegerSamplerType  string  `config:"jaeger-agent-sampler-type" env:"PATRON_JAEGER_SAMPLER_TYPE" default:"probabilistic"`
+	JaegerSamplerParam float64 `config:"jaeger-agent-sampler-param" env:"PATRON_JAEGER_SAMPLER_PARAM" default:"0.0"`
+	HTTPPort           int     `config:"http-default-port" env:"PATRON_HTTP_DEFAULT_PORT" default:"50000"`
+}
+
+// Validate the service configuration.
+func (c *serviceConfig) Validate() error {
+	switch log.Level(c.LogLevel) {
+	case log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel, log.FatalLevel, log.PanicLevel, log.NoLevel:
+	default:
+		return errors.Errorf("log level %s is not valid", c.LogLevel)
+	}
+	if c.JaegerSamplerType == "" {
+		return errors.New("jaeger sampler type is required")
+	}
+	if c.HTTPPort <= 0 || c.HTTPPort > 65535 {
+		return errors.Errorf("HTTP default port %d is not valid", c.HTTPPort)
+	}
+	return nil
+}
+
+func loadConfig(file string) (*serviceConfig, error) {
+	var oo []config.OptionFunc
+	if file != "" {
+		oo = append(oo, config.File(file))
+	}
+	cfg := serviceConfig{}
+	err := config.Load(&cfg, oo...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load service config")
+	}
+	return &cfg, nil
+}
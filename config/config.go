@@ -0,0 +1,382 @@
+// Package config provides loading of typed configuration from defaults, files, environment variables and flags.
+//
+// The configuration is described by a struct whose fields are annotated with the following tags:
+//
+//  - config, the name of the value, used as the key in files and when publishing to the service info
+//  - env, the environment variable to read the value from
+//  - flag, the command line flag to read the value from
+//  - default, the default value
+//  - required, when "true" a value has to be provided by at least one source
+//  - secret, when "true" the value is redacted when published
+//  - desc, the description of the value used in the flag usage
+//
+// Sources are applied with the following precedence, from lowest to highest:
+// default, file, environment variable and flag.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mantzas/patron/encoding/json"
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/info"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const redacted = "***"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Validator interface which can be implemented by configuration structs in order to be validated after loading.
+type Validator interface {
+	Validate() error
+}
+
+type field struct {
+	name     string
+	env      string
+	flag     string
+	def      string
+	desc     string
+	required bool
+	secret   bool
+	set      bool
+	value    reflect.Value
+}
+
+type loader struct {
+	file    string
+	args    []string
+	publish bool
+	lookup  func(string) (string, bool)
+}
+
+// Load populates the struct pointed to by v from all configured sources, validates
+// and publishes the effective values to the service info.
+func Load(v interface{}, oo ...OptionFunc) error {
+	l := loader{publish: true, lookup: os.LookupEnv}
+	for _, o := range oo {
+		err := o(&l)
+		if err != nil {
+			return err
+		}
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config has to be a non nil pointer to a struct")
+	}
+
+	ff, err := fields(rv.Elem())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range ff {
+		if f.def == "" {
+			continue
+		}
+		err = f.setValue(f.def, "default")
+		if err != nil {
+			return err
+		}
+		f.set = false
+	}
+
+	err = l.loadFile(ff)
+	if err != nil {
+		return err
+	}
+
+	err = l.loadEnv(ff)
+	if err != nil {
+		return err
+	}
+
+	err = l.loadFlags(ff)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range ff {
+		if f.required && !f.set && f.def == "" {
+			return errors.Errorf("config %s is required", f.name)
+		}
+	}
+
+	if vl, ok := v.(Validator); ok {
+		err = vl.Validate()
+		if err != nil {
+			return errors.Wrap(err, "config is not valid")
+		}
+	}
+
+	if l.publish {
+		for _, f := range ff {
+			info.UpsertConfig(f.name, f.published())
+		}
+	}
+	return nil
+}
+
+func fields(rv reflect.Value) ([]*field, error) {
+	var ff []*field
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		fv := rv.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, ok := sf.Tag.Lookup("config")
+		if !ok {
+			if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+				nested, err := fields(fv)
+				if err != nil {
+					return nil, err
+				}
+				ff = append(ff, nested...)
+			}
+			continue
+		}
+		if name == "" {
+			return nil, errors.Errorf("config tag of field %s is empty", sf.Name)
+		}
+		if !supported(fv) {
+			return nil, errors.Errorf("type %s of config %s is not supported", fv.Type(), name)
+		}
+		ff = append(ff, &field{
+			name:     name,
+			env:      sf.Tag.Get("env"),
+			flag:     sf.Tag.Get("flag"),
+			def:      sf.Tag.Get("default"),
+			desc:     sf.Tag.Get("desc"),
+			required: sf.Tag.Get("required") == "true",
+			secret:   sf.Tag.Get("secret") == "true",
+			value:    fv,
+		})
+	}
+	return ff, nil
+}
+
+func supported(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return v.Type().Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+func (l *loader) loadFile(ff []*field) error {
+	if l.file == "" {
+		return nil
+	}
+	cnt, err := ioutil.ReadFile(l.file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read config file %s", l.file)
+	}
+
+	vv := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(l.file)) {
+	case ".json":
+		err = json.DecodeRaw(cnt, &vv)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(cnt, &vv)
+	default:
+		return errors.Errorf("config file %s has a unsupported extension", l.file)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode config file %s", l.file)
+	}
+
+	for _, f := range ff {
+		v, ok := vv[f.name]
+		if !ok || v == nil {
+			continue
+		}
+		err = f.setValue(fileValue(v), "file")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fileValue(v interface{}) string {
+	switch x := v.(type) {
+	case []interface{}:
+		ss := make([]string, len(x))
+		for i, e := range x {
+			ss[i] = fmt.Sprint(e)
+		}
+		return strings.Join(ss, ",")
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (l *loader) loadEnv(ff []*field) error {
+	for _, f := range ff {
+		if f.env == "" {
+			continue
+		}
+		v, ok := l.lookup(f.env)
+		if !ok {
+			continue
+		}
+		err := f.setValue(v, "env var "+f.env)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *loader) loadFlags(ff []*field) error {
+	if l.args == nil {
+		return nil
+	}
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	vv := make(map[string]*string)
+	for _, f := range ff {
+		if f.flag == "" {
+			continue
+		}
+		vv[f.flag] = fs.String(f.flag, f.def, f.desc)
+	}
+	err := fs.Parse(knownArgs(l.args, vv))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse flags")
+	}
+
+	visited := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) {
+		visited[fl.Name] = true
+	})
+
+	for _, f := range ff {
+		if !visited[f.flag] {
+			continue
+		}
+		err = f.setValue(*vv[f.flag], "flag "+f.flag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// knownArgs returns the arguments of the known flags, so that the arguments of other flags of the binary
+// are ignored. The argument following a unknown flag without a value is considered its value, unless it is a flag.
+func knownArgs(args []string, known map[string]*string) []string {
+	var kk []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		hasValue := strings.Contains(name, "=")
+		if hasValue {
+			name = name[:strings.Index(name, "=")]
+		}
+		_, ok := known[name]
+		if ok {
+			kk = append(kk, arg)
+		}
+		if hasValue || i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
+			continue
+		}
+		i++
+		if ok {
+			kk = append(kk, args[i])
+		}
+	}
+	return kk
+}
+
+func (f *field) setValue(s, src string) error {
+	err := parse(f.value, s)
+	if err != nil {
+		return errors.Wrapf(err, "config %s from %s is not valid", f.name, src)
+	}
+	f.set = true
+	return nil
+}
+
+func parse(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		fl, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(fl)
+	case reflect.Slice:
+		var ss []string
+		for _, e := range strings.Split(s, ",") {
+			e = strings.TrimSpace(e)
+			if e != "" {
+				ss = append(ss, e)
+			}
+		}
+		v.Set(reflect.ValueOf(ss))
+	}
+	return nil
+}
+
+func (f *field) published() interface{} {
+	if f.secret {
+		return redacted
+	}
+	if f.value.Type() == durationType {
+		return time.Duration(f.value.Int()).String()
+	}
+	return f.value.Interface()
+}
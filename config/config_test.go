@@ -0,0 +1,180 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/info"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Name     string        `config:"name" env:"TEST_CONFIG_NAME" flag:"name" default:"default"`
+	Port     int           `config:"port" env:"TEST_CONFIG_PORT" flag:"port" default:"1000"`
+	Ratio    float64       `config:"ratio" env:"TEST_CONFIG_RATIO"`
+	Enabled  bool          `config:"enabled" flag:"enabled" default:"true"`
+	Timeout  time.Duration `config:"timeout" env:"TEST_CONFIG_TIMEOUT" default:"1s"`
+	Brokers  []string      `config:"brokers" env:"TEST_CONFIG_BROKERS"`
+	Password string        `config:"password" env:"TEST_CONFIG_PASSWORD" secret:"true"`
+	Nested   nestedConfig
+	ignored  string
+}
+
+type nestedConfig struct {
+	Retries uint `config:"retries" default:"3"`
+}
+
+type requiredConfig struct {
+	Name string `config:"name" required:"true"`
+}
+
+type validatedConfig struct {
+	Port int `config:"port" default:"0"`
+}
+
+func (vc *validatedConfig) Validate() error {
+	if vc.Port <= 0 {
+		return errors.New("port has to be positive")
+	}
+	return nil
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg := testConfig{}
+	assert.NoError(t, Load(&cfg))
+	assert.Equal(t, "default", cfg.Name)
+	assert.Equal(t, 1000, cfg.Port)
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, time.Second, cfg.Timeout)
+	assert.Equal(t, uint(3), cfg.Nested.Retries)
+	assert.Empty(t, cfg.Brokers)
+}
+
+func TestLoad_File(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "yaml", file: "testdata/config.yaml"},
+		{name: "json", file: "testdata/config.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig{}
+			assert.NoError(t, Load(&cfg, File(tt.file)))
+			assert.Equal(t, "from-file", cfg.Name)
+			assert.Equal(t, 8080, cfg.Port)
+			assert.Equal(t, 0.5, cfg.Ratio)
+			assert.Equal(t, []string{"broker1", "broker2"}, cfg.Brokers)
+			assert.Equal(t, "secret", cfg.Password)
+		})
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	setEnv(t, "TEST_CONFIG_NAME", "from-env")
+	setEnv(t, "TEST_CONFIG_PORT", "9090")
+	setEnv(t, "TEST_CONFIG_BROKERS", "broker3, broker4")
+	defer unsetEnv(t, "TEST_CONFIG_NAME", "TEST_CONFIG_PORT", "TEST_CONFIG_BROKERS")
+
+	cfg := testConfig{}
+	assert.NoError(t, Load(&cfg, File("testdata/config.yaml"), Flags([]string{"-name", "from-flag", "-enabled=false"})))
+	assert.Equal(t, "from-flag", cfg.Name)
+	assert.Equal(t, 9090, cfg.Port)
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.False(t, cfg.Enabled)
+	assert.Equal(t, []string{"broker3", "broker4"}, cfg.Brokers)
+}
+
+func TestLoad_UnknownFlags(t *testing.T) {
+	cfg := testConfig{}
+	args := []string{"-v", "-unknown", "1", "--name=from-flag", "-other=2", "positional", "-port", "8080", "--", "-enabled=false"}
+	assert.NoError(t, Load(&cfg, Flags(args), NoPublish()))
+	assert.Equal(t, "from-flag", cfg.Name)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.True(t, cfg.Enabled)
+}
+
+func Test_knownArgs(t *testing.T) {
+	known := map[string]*string{"name": nil, "port": nil}
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "none", args: nil, want: nil},
+		{name: "known", args: []string{"-name", "n", "--port=1"}, want: []string{"-name", "n", "--port=1"}},
+		{name: "unknown with value", args: []string{"-x", "1", "-name", "n"}, want: []string{"-name", "n"}},
+		{name: "unknown without value", args: []string{"-x", "-name", "n"}, want: []string{"-name", "n"}},
+		{name: "known without value", args: []string{"-x", "1", "-name"}, want: []string{"-name"}},
+		{name: "terminated", args: []string{"-name", "n", "--", "-port", "1"}, want: []string{"-name", "n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, knownArgs(tt.args, known))
+		})
+	}
+}
+
+func TestLoad_Publish(t *testing.T) {
+	cfg := testConfig{}
+	assert.NoError(t, Load(&cfg, File("testdata/config.json")))
+	b, err := info.Marshal()
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"password":"***"`)
+	assert.Contains(t, string(b), `"timeout":"1s"`)
+	assert.NotContains(t, string(b), "secret")
+}
+
+func TestLoad_Failures(t *testing.T) {
+	setEnv(t, "TEST_CONFIG_TIMEOUT", "XXX")
+	defer unsetEnv(t, "TEST_CONFIG_TIMEOUT")
+	tests := []struct {
+		name string
+		cfg  interface{}
+		oo   []OptionFunc
+	}{
+		{name: "not a pointer", cfg: testConfig{}},
+		{name: "nil pointer", cfg: (*testConfig)(nil)},
+		{name: "not a struct", cfg: new(string)},
+		{name: "unsupported type", cfg: &struct {
+			M map[string]string `config:"map"`
+		}{}},
+		{name: "empty config tag", cfg: &struct {
+			S string `config:""`
+		}{}},
+		{name: "invalid default", cfg: &struct {
+			I int `config:"int" default:"XXX"`
+		}{}},
+		{name: "invalid env var", cfg: &testConfig{}},
+		{name: "missing required", cfg: &requiredConfig{}},
+		{name: "failed validation", cfg: &validatedConfig{}},
+		{name: "missing file", cfg: &requiredConfig{}, oo: []OptionFunc{File("testdata/missing.yaml")}},
+		{name: "unsupported file", cfg: &requiredConfig{}, oo: []OptionFunc{File("testdata/config.toml")}},
+		{name: "invalid file", cfg: &requiredConfig{}, oo: []OptionFunc{File("testdata/invalid.yaml")}},
+		{name: "option failure", cfg: &requiredConfig{}, oo: []OptionFunc{File("")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, Load(tt.cfg, tt.oo...))
+		})
+	}
+}
+
+func TestLoad_Required(t *testing.T) {
+	cfg := requiredConfig{}
+	assert.NoError(t, Load(&cfg, File("testdata/config.yaml"), NoPublish()))
+	assert.Equal(t, "from-file", cfg.Name)
+}
+
+func setEnv(t *testing.T, key, value string) {
+	assert.NoError(t, os.Setenv(key, value))
+}
+
+func unsetEnv(t *testing.T, keys ...string) {
+	for _, k := range keys {
+		assert.NoError(t, os.Unsetenv(k))
+	}
+}
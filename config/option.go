@@ -0,0 +1,39 @@
+package config
+
+import (
+	"github.com/mantzas/patron/errors"
+)
+
+// OptionFunc definition for configuring the loading in a functional way.
+type OptionFunc func(*loader) error
+
+// File option for loading configuration from a YAML or JSON file, determined by the extension.
+func File(file string) OptionFunc {
+	return func(l *loader) error {
+		if file == "" {
+			return errors.New("file is required")
+		}
+		l.file = file
+		return nil
+	}
+}
+
+// Flags option for loading configuration from command line arguments, e.g. os.Args[1:].
+// Arguments of flags which are not defined by the configuration struct are ignored.
+func Flags(args []string) OptionFunc {
+	return func(l *loader) error {
+		if args == nil {
+			args = []string{}
+		}
+		l.args = args
+		return nil
+	}
+}
+
+// NoPublish option for not publishing the effective configuration to the service info.
+func NoPublish() OptionFunc {
+	return func(l *loader) error {
+		l.publish = false
+		return nil
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{name: "success", file: "config.yaml", wantErr: false},
+		{name: "failed due to empty file", file: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := loader{}
+			err := File(tt.file)(&l)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.file, l.file)
+			}
+		})
+	}
+}
+
+func TestFlags(t *testing.T) {
+	l := loader{}
+	assert.NoError(t, Flags(nil)(&l))
+	assert.NotNil(t, l.args)
+}
+
+func TestNoPublish(t *testing.T) {
+	l := loader{publish: true}
+	assert.NoError(t, NoPublish()(&l))
+	assert.False(t, l.publish)
+}
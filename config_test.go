@@ -0,0 +1,37 @@
+package patron
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceConfig_Validate(t *testing.T) {
+	valid := serviceConfig{LogLevel: "info", JaegerSamplerType: "const", HTTPPort: 50000}
+	invalidLevel := valid
+	invalidLevel.LogLevel = "XXX"
+	missingSampler := valid
+	missingSampler.JaegerSamplerType = ""
+	invalidPort := valid
+	invalidPort.HTTPPort = 70000
+	tests := []struct {
+		name    string
+		cfg     serviceConfig
+		wantErr bool
+	}{
+		{name: "success", cfg: valid, wantErr: false},
+		{name: "invalid log level", cfg: invalidLevel, wantErr: true},
+		{name: "missing sampler type", cfg: missingSampler, wantErr: true},
+		{name: "invalid port", cfg: invalidPort, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package errors
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Panic error created from a recovered panic, holding the stack trace of the goroutine that panicked.
+type Panic struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns the string representation of the recovered value.
+func (p *Panic) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// FromPanic converts a recovered value into a error with the current stack trace.
+// It has to be called from the deferred function that recovered the panic.
+func FromPanic(r interface{}) *Panic {
+	return &Panic{Value: r, Stack: debug.Stack()}
+}
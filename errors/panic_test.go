@@ -0,0 +1,24 @@
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromPanic(t *testing.T) {
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = FromPanic(r)
+			}
+		}()
+		panic("TEST")
+	}()
+	assert.EqualError(t, err, "panic: TEST")
+	p, ok := err.(*Panic)
+	assert.True(t, ok)
+	assert.Equal(t, "TEST", p.Value)
+	assert.Contains(t, string(p.Stack), "TestFromPanic")
+}
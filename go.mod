@@ -1,34 +1,62 @@
 module github.com/mantzas/patron
 
+go 1.27.1
+
 require (
 	github.com/Shopify/sarama v1.16.0
+	github.com/golang/protobuf v1.2.0
+	github.com/google/uuid v1.1.0
+	github.com/julienschmidt/httprouter v1.2.0
+	github.com/opentracing-contrib/go-stdlib v0.0.0-20180313041242-367231351874
+	github.com/opentracing/opentracing-go v0.0.0-20180606204148-bd9c31933947
+	github.com/pkg/errors v0.8.0
+	github.com/prometheus/client_golang v0.9.1
+	github.com/prometheus/client_model v0.0.0-20190129233127-fd36f4220a90
+	github.com/rs/zerolog v1.5.0
+	github.com/streadway/amqp v0.0.0-20180315184602-8e4aba63da9f
+	github.com/stretchr/testify v1.2.2
+	github.com/uber/jaeger-client-go v2.15.0+incompatible
+	github.com/uber/jaeger-lib v1.5.0
+	gopkg.in/russross/blackfriday.v2 v2.0.0
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
 	github.com/Shopify/toxiproxy v2.1.4+incompatible // indirect
+	github.com/alecthomas/template v0.0.0-20160405071501-a0175ee3bccc // indirect
+	github.com/alecthomas/units v0.0.0-20151022065526-2efee857e7cf // indirect
+	github.com/beorn7/perks v0.0.0-20180321164747-3a771d992973 // indirect
 	github.com/codahale/hdrhistogram v0.0.0-20161010025455-3a0bb77429bd // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/eapache/go-resiliency v1.1.0 // indirect
 	github.com/eapache/go-xerial-snappy v0.0.0-20180814174437-776d5712da21 // indirect
 	github.com/eapache/queue v1.1.0 // indirect
+	github.com/go-kit/kit v0.8.0 // indirect
+	github.com/go-logfmt/logfmt v0.3.0 // indirect
+	github.com/go-stack/stack v1.8.0 // indirect
 	github.com/gogo/protobuf v1.2.1 // indirect
-	github.com/golang/protobuf v1.2.0
 	github.com/golang/snappy v0.0.1 // indirect
-	github.com/google/uuid v1.1.0
-	github.com/julienschmidt/httprouter v1.2.0
-	github.com/opentracing-contrib/go-stdlib v0.0.0-20180313041242-367231351874
-	github.com/opentracing/opentracing-go v0.0.0-20180606204148-bd9c31933947
+	github.com/kisielk/errcheck v1.1.0 // indirect
+	github.com/kisielk/gotool v1.0.0 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/kr/logfmt v0.0.0-20140226030751-b84e30acd515 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/mwitkow/go-conntrack v0.0.0-20161129095857-cc309e4a2223 // indirect
 	github.com/pierrec/lz4 v2.0.5+incompatible // indirect
-	github.com/pkg/errors v0.8.0
-	github.com/prometheus/client_golang v0.9.1
-	github.com/prometheus/client_model v0.0.0-20190129233127-fd36f4220a90 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/prometheus/common v0.2.0 // indirect
 	github.com/prometheus/procfs v0.0.0-20190315082738-e56f2e22fc76 // indirect
 	github.com/rcrowley/go-metrics v0.0.0-20181016184325-3113b8401b8a // indirect
-	github.com/rs/zerolog v1.5.0
 	github.com/shurcooL/sanitized_anchor_name v1.0.0 // indirect
-	github.com/streadway/amqp v0.0.0-20180315184602-8e4aba63da9f
-	github.com/stretchr/testify v1.2.2
+	github.com/sirupsen/logrus v1.2.0 // indirect
+	github.com/stretchr/objx v0.1.1 // indirect
 	github.com/uber-go/atomic v1.3.2 // indirect
-	github.com/uber/jaeger-client-go v2.15.0+incompatible
-	github.com/uber/jaeger-lib v1.5.0
 	go.uber.org/atomic v1.3.2 // indirect
+	golang.org/x/crypto v0.0.0-20180904163835-0709b304e793 // indirect
+	golang.org/x/net v0.0.0-20181114220301-adae6a3d119a // indirect
 	golang.org/x/sync v0.0.0-20190227155943-e225da77a7e6 // indirect
-	gopkg.in/russross/blackfriday.v2 v2.0.0
+	golang.org/x/sys v0.0.0-20181116152217-5ac8a444bdc5 // indirect
+	golang.org/x/tools v0.0.0-20180221164845-07fd8470d635 // indirect
+	gopkg.in/alecthomas/kingpin.v2 v2.2.6 // indirect
+	gopkg.in/check.v1 v0.0.0-20161208181325-20d25e280405 // indirect
 )
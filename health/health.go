@@ -0,0 +1,233 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+)
+
+const defaultTimeout = time.Second
+
+// Kind of a check, which defines the probe the check participates in.
+type Kind int
+
+const (
+	// Liveness checks report whether the service is alive or has to be restarted.
+	Liveness Kind = iota
+	// Readiness checks report whether the service is able to handle work.
+	Readiness
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Liveness:
+		return "liveness"
+	case Readiness:
+		return "readiness"
+	default:
+		return "unknown"
+	}
+}
+
+// Status of a check or a probe.
+type Status string
+
+const (
+	// Up status.
+	Up Status = "up"
+	// Down status.
+	Down Status = "down"
+)
+
+// Reporter interface which components can implement in order to report their health status,
+// which is aggregated into the health of the service.
+type Reporter interface {
+	Health() Status
+}
+
+// CheckFunc defines a function type for implementing a check.
+// A check succeeds when it returns no error.
+type CheckFunc func(ctx context.Context) error
+
+// Pinger interface implemented by resources which can be pinged, e.g. trace/sql DB.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck returns a check which pings the provided resource.
+func PingCheck(p Pinger) CheckFunc {
+	return func(ctx context.Context) error {
+		return p.Ping(ctx)
+	}
+}
+
+// Result of a check.
+type Result struct {
+	Name        string `json:"name"`
+	Status      Status `json:"status"`
+	Error       string `json:"error,omitempty"`
+	LastError   string `json:"last-error,omitempty"`
+	LastErrorAt string `json:"last-error-at,omitempty"`
+	CheckedAt   string `json:"checked-at"`
+	Duration    string `json:"duration"`
+}
+
+// Report of a probe, listing the result of each check of the probe.
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+type check struct {
+	name    string
+	kind    Kind
+	f       CheckFunc
+	timeout time.Duration
+	ttl     time.Duration
+
+	sync.Mutex
+	err       error
+	checkedAt time.Time
+	duration  time.Duration
+	lastErr   error
+	lastErrAt time.Time
+}
+
+var (
+	checks = make(map[string]*check)
+	mu     = sync.Mutex{}
+)
+
+// Register a named check of the provided kind. A check registered with the same name is replaced.
+// Use options to change the default timeout of one second, and to cache the result.
+func Register(name string, k Kind, f CheckFunc, oo ...OptionFunc) error {
+	return register(name, k, f, true, oo...)
+}
+
+// RegisterUnique registers a named check like Register, but fails when a check with the same name is registered.
+func RegisterUnique(name string, k Kind, f CheckFunc, oo ...OptionFunc) error {
+	return register(name, k, f, false, oo...)
+}
+
+func register(name string, k Kind, f CheckFunc, replace bool, oo ...OptionFunc) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if f == nil {
+		return errors.New("check func is required")
+	}
+	if k != Liveness && k != Readiness {
+		return errors.Errorf("invalid kind %d", k)
+	}
+
+	c := &check{name: name, kind: k, f: f, timeout: defaultTimeout}
+	for _, o := range oo {
+		err := o(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := checks[name]; ok && !replace {
+		return errors.Errorf("check %s is already registered", name)
+	}
+	checks[name] = c
+	return nil
+}
+
+// Deregister the named check.
+func Deregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(checks, name)
+}
+
+// Evaluate runs concurrently all checks of the provided kind and reports their results.
+// The report is down if any check fails.
+func Evaluate(ctx context.Context, k Kind) Report {
+	mu.Lock()
+	cc := make([]*check, 0, len(checks))
+	for _, c := range checks {
+		if c.kind == k {
+			cc = append(cc, c)
+		}
+	}
+	mu.Unlock()
+	sort.Slice(cc, func(i, j int) bool { return cc[i].name < cc[j].name })
+
+	rr := make([]Result, len(cc))
+	wg := sync.WaitGroup{}
+	for i, c := range cc {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			rr[i] = c.evaluate(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	rep := Report{Status: Up, Checks: rr}
+	for _, r := range rr {
+		if r.Status == Down {
+			rep.Status = Down
+			break
+		}
+	}
+	return rep
+}
+
+// evaluate runs the check, unless a cached result is still valid, and returns its result.
+func (c *check) evaluate(ctx context.Context) Result {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.ttl == 0 || c.checkedAt.IsZero() || time.Since(c.checkedAt) >= c.ttl {
+		start := time.Now()
+		c.err = c.run(ctx)
+		c.checkedAt = start
+		c.duration = time.Since(start)
+		if c.err != nil {
+			c.lastErr = c.err
+			c.lastErrAt = start
+		}
+	}
+
+	r := Result{
+		Name:      c.name,
+		Status:    Up,
+		CheckedAt: c.checkedAt.Format(time.RFC3339),
+		Duration:  c.duration.String(),
+	}
+	if c.err != nil {
+		r.Status = Down
+		r.Error = c.err.Error()
+	}
+	if c.lastErr != nil {
+		r.LastError = c.lastErr.Error()
+		r.LastErrorAt = c.lastErrAt.Format(time.RFC3339)
+	}
+	return r
+}
+
+// run executes the check func, failing the check if it does not return within the timeout.
+func (c *check) run(ctx context.Context) error {
+	ctx, cnl := context.WithTimeout(ctx, c.timeout)
+	defer cnl()
+
+	ch := make(chan error, 1)
+	go func() {
+		ch <- c.f(ctx)
+	}()
+
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return errors.Errorf("check timed out after %v", c.timeout)
+	}
+}
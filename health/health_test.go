@@ -0,0 +1,150 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func okCheck(ctx context.Context) error {
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	type args struct {
+		name string
+		k    Kind
+		f    CheckFunc
+		oo   []OptionFunc
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{"success", args{name: "test", k: Readiness, f: okCheck}, false},
+		{"success with options", args{name: "test", k: Liveness, f: okCheck, oo: []OptionFunc{Timeout(time.Second), Cache(time.Second)}}, false},
+		{"failure missing name", args{name: "", k: Readiness, f: okCheck}, true},
+		{"failure missing func", args{name: "test", k: Readiness, f: nil}, true},
+		{"failure invalid kind", args{name: "test", k: Kind(5), f: okCheck}, true},
+		{"failure invalid option", args{name: "test", k: Readiness, f: okCheck, oo: []OptionFunc{Timeout(0)}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer Deregister(tt.args.name)
+			err := Register(tt.args.name, tt.args.k, tt.args.f, tt.args.oo...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Contains(t, checks, tt.args.name)
+			}
+		})
+	}
+}
+
+func TestRegisterUnique(t *testing.T) {
+	defer Deregister("unique")
+	assert.NoError(t, RegisterUnique("unique", Readiness, okCheck))
+	assert.Error(t, RegisterUnique("unique", Liveness, okCheck))
+	assert.Equal(t, Readiness, checks["unique"].kind)
+	assert.NoError(t, Register("unique", Liveness, okCheck))
+	assert.Equal(t, Liveness, checks["unique"].kind)
+}
+
+func TestEvaluate(t *testing.T) {
+	failing := func(ctx context.Context) error { return errors.New("TEST") }
+	blocking := func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+	assert.NoError(t, Register("live", Liveness, okCheck))
+	defer Deregister("live")
+	assert.NoError(t, Register("ok", Readiness, okCheck))
+	defer Deregister("ok")
+
+	rep := Evaluate(context.Background(), Liveness)
+	assert.Equal(t, Up, rep.Status)
+	assert.Len(t, rep.Checks, 1)
+	assert.Equal(t, "live", rep.Checks[0].Name)
+
+	assert.NoError(t, Register("failing", Readiness, failing))
+	assert.NoError(t, Register("blocking", Readiness, blocking, Timeout(10*time.Millisecond)))
+	defer Deregister("blocking")
+
+	rep = Evaluate(context.Background(), Readiness)
+	assert.Equal(t, Down, rep.Status)
+	assert.Len(t, rep.Checks, 3)
+	assert.Equal(t, "blocking", rep.Checks[0].Name)
+	assert.Equal(t, Down, rep.Checks[0].Status)
+	assert.Equal(t, "check timed out after 10ms", rep.Checks[0].Error)
+	assert.Equal(t, "failing", rep.Checks[1].Name)
+	assert.Equal(t, "TEST", rep.Checks[1].Error)
+	assert.Equal(t, "TEST", rep.Checks[1].LastError)
+	assert.Equal(t, Up, rep.Checks[2].Status)
+
+	Deregister("failing")
+	assert.Equal(t, Down, Evaluate(context.Background(), Readiness).Status)
+	Deregister("blocking")
+	assert.Equal(t, Up, Evaluate(context.Background(), Readiness).Status)
+}
+
+func TestEvaluate_Cache(t *testing.T) {
+	var err error
+	calls := 0
+	f := func(ctx context.Context) error {
+		calls++
+		return err
+	}
+	assert.NoError(t, Register("cached", Readiness, f, Cache(time.Hour)))
+	defer Deregister("cached")
+
+	err = errors.New("TEST")
+	rep := Evaluate(context.Background(), Readiness)
+	assert.Equal(t, Down, rep.Status)
+	err = nil
+	rep = Evaluate(context.Background(), Readiness)
+	assert.Equal(t, Down, rep.Status)
+	assert.Equal(t, 1, calls)
+}
+
+func TestEvaluate_LastError(t *testing.T) {
+	err := errors.New("TEST")
+	f := func(ctx context.Context) error {
+		return err
+	}
+	assert.NoError(t, Register("recovering", Readiness, f))
+	defer Deregister("recovering")
+
+	rep := Evaluate(context.Background(), Readiness)
+	assert.Equal(t, Down, rep.Status)
+	err = nil
+	rep = Evaluate(context.Background(), Readiness)
+	assert.Equal(t, Up, rep.Status)
+	assert.Empty(t, rep.Checks[0].Error)
+	assert.Equal(t, "TEST", rep.Checks[0].LastError)
+	assert.NotEmpty(t, rep.Checks[0].LastErrorAt)
+}
+
+type pinger struct {
+	err error
+}
+
+func (p pinger) Ping(ctx context.Context) error {
+	return p.err
+}
+
+func TestPingCheck(t *testing.T) {
+	assert.NoError(t, PingCheck(pinger{})(context.Background()))
+	assert.Error(t, PingCheck(pinger{err: errors.New("TEST")})(context.Background()))
+}
+
+func TestKind_String(t *testing.T) {
+	assert.Equal(t, "liveness", Liveness.String())
+	assert.Equal(t, "readiness", Readiness.String())
+	assert.Equal(t, "unknown", Kind(5).String())
+}
@@ -0,0 +1,32 @@
+package health
+
+import (
+	"time"
+
+	"github.com/mantzas/patron/errors"
+)
+
+// OptionFunc definition for configuring the check in a functional way.
+type OptionFunc func(*check) error
+
+// Timeout option for setting the time the check has to return before it fails.
+func Timeout(d time.Duration) OptionFunc {
+	return func(c *check) error {
+		if d <= 0 {
+			return errors.New("timeout must be positive")
+		}
+		c.timeout = d
+		return nil
+	}
+}
+
+// Cache option for reusing the result of the check for the provided duration.
+func Cache(ttl time.Duration) OptionFunc {
+	return func(c *check) error {
+		if ttl <= 0 {
+			return errors.New("cache duration must be positive")
+		}
+		c.ttl = ttl
+		return nil
+	}
+}
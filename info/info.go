@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"sync"
+	"time"
 
 	"github.com/mantzas/patron/encoding/json"
 	"github.com/mantzas/patron/errors"
@@ -18,6 +19,13 @@ type info struct {
 	Components []map[string]interface{} `json:"components,omitempty"`
 	Metrics    map[string]string        `json:"metrics,omitempty"`
 	Doc        string                   `json:"doc,omitempty"`
+	Reload     *reload                  `json:"reload,omitempty"`
+}
+
+type reload struct {
+	Time   string `json:"time"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
 }
 
 var (
@@ -94,3 +102,15 @@ func AppendComponent(i map[string]interface{}) {
 	defer mu.Unlock()
 	serviceInfo.Components = append(serviceInfo.Components, i)
 }
+
+// UpdateReload to the info with the time and the result of the last configuration reload.
+func UpdateReload(t time.Time, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	r := reload{Time: t.Format(time.RFC3339), Result: "success"}
+	if err != nil {
+		r.Result = "failure"
+		r.Error = err.Error()
+	}
+	serviceInfo.Reload = &r
+}
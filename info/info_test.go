@@ -2,7 +2,9 @@ package info
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -61,3 +63,11 @@ func TestAddDoc(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateReload(t *testing.T) {
+	now := time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	UpdateReload(now, nil)
+	assert.Equal(t, &reload{Time: "2019-03-01T10:00:00Z", Result: "success"}, serviceInfo.Reload)
+	UpdateReload(now, errors.New("TEST"))
+	assert.Equal(t, &reload{Time: "2019-03-01T10:00:00Z", Result: "failure", Error: "TEST"}, serviceInfo.Reload)
+}
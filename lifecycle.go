@@ -0,0 +1,74 @@
+package patron
+
+import (
+	"context"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+)
+
+// Lifecycle interface which can be optionally implemented by components in order to
+// hook into the lifecycle of the service. The service calls the hooks in the following order:
+//
+//  - OnStart, for each component in the order of registration, before any component is run
+//  - OnReady, for each component in the order of registration, after all components have been run
+//  - OnStop, for each component after it has stopped running, during the service shutdown
+type Lifecycle interface {
+	OnStart(ctx context.Context) error
+	OnReady(ctx context.Context) error
+	OnStop(ctx context.Context) error
+}
+
+// HookFunc definition of a service lifecycle hook.
+type HookFunc func(ctx context.Context) error
+
+func runHooks(ctx context.Context, stage string, hh []HookFunc) error {
+	var ee []error
+	for i, h := range hh {
+		err := h(ctx)
+		if err != nil {
+			ee = append(ee, errors.Wrapf(err, "%s hook %d failed", stage, i))
+		}
+	}
+	return errors.Aggregate(ee...)
+}
+
+// startComponents calls the OnStart hook of every component implementing Lifecycle and returns
+// the number of components that were started successfully.
+func startComponents(ctx context.Context, rr []*runner) (int, error) {
+	for i, r := range rr {
+		lc, ok := r.cp.(Lifecycle)
+		if !ok {
+			continue
+		}
+		log.Debugf("starting component %s", r.name)
+		err := lc.OnStart(ctx)
+		if err != nil {
+			return i, errors.Wrapf(err, "component %s failed to start", r.name)
+		}
+	}
+	return len(rr), nil
+}
+
+func readyComponents(ctx context.Context, rr []*runner) error {
+	for _, r := range rr {
+		lc, ok := r.cp.(Lifecycle)
+		if !ok {
+			continue
+		}
+		err := lc.OnReady(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "component %s failed to get ready", r.name)
+		}
+	}
+	return nil
+}
+
+func stopComponent(ctx context.Context, r *runner) error {
+	lc, ok := r.cp.(Lifecycle)
+	if !ok {
+		return nil
+	}
+	log.Debugf("calling stop hook of component %s", r.name)
+	return errors.Wrapf(lc.OnStop(ctx), "component %s failed to stop", r.name)
+}
@@ -0,0 +1,130 @@
+package patron
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Run_Lifecycle(t *testing.T) {
+	rec := &recorder{}
+	hook := func(event string) func(context.Context) error {
+		return func(context.Context) error {
+			rec.record(event)
+			return nil
+		}
+	}
+	s, err := New("test", "",
+		Components(&lifecycleComponent{rec: rec}),
+		OnStart(hook("service-start")),
+		OnReady(hook("service-ready")),
+		OnStop(hook("service-stop")),
+	)
+	assert.NoError(t, err)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.termSig <- syscall.SIGTERM
+	}()
+	assert.NoError(t, s.Run())
+	assert.Equal(t, []string{
+		"service-start",
+		"component-start",
+		"component-ready",
+		"service-ready",
+		"component-run-exit",
+		"component-stop",
+		"service-stop",
+	}, rec.get())
+}
+
+func TestServer_Run_Lifecycle_StartFailure(t *testing.T) {
+	rec := &recorder{}
+	s, err := New("test", "",
+		Components(&lifecycleComponent{rec: rec}, &lifecycleComponent{rec: rec, failStart: true}),
+	)
+	assert.NoError(t, err)
+	err = s.Run()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"component-start", "component-start", "component-stop"}, rec.get())
+}
+
+func TestServer_Run_Lifecycle_StartHookFailure(t *testing.T) {
+	rec := &recorder{}
+	s, err := New("test", "",
+		Components(&lifecycleComponent{rec: rec}),
+		OnStart(func(context.Context) error { return errors.New("TEST") }),
+	)
+	assert.NoError(t, err)
+	assert.Error(t, s.Run())
+	assert.Empty(t, rec.get())
+}
+
+func TestServer_Run_Lifecycle_ReadyFailure(t *testing.T) {
+	rec := &recorder{}
+	s, err := New("test", "",
+		Components(&lifecycleComponent{rec: rec, failReady: true}),
+	)
+	assert.NoError(t, err)
+	err = s.Run()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"component-start", "component-ready", "component-run-exit", "component-stop"}, rec.get())
+}
+
+type recorder struct {
+	sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.Lock()
+	defer r.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) get() []string {
+	r.Lock()
+	defer r.Unlock()
+	return r.events
+}
+
+type lifecycleComponent struct {
+	rec       *recorder
+	failStart bool
+	failReady bool
+}
+
+func (lc *lifecycleComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	lc.rec.record("component-run-exit")
+	return nil
+}
+
+func (lc *lifecycleComponent) Info() map[string]interface{} {
+	return map[string]interface{}{"type": "lifecycle"}
+}
+
+func (lc *lifecycleComponent) OnStart(ctx context.Context) error {
+	lc.rec.record("component-start")
+	if lc.failStart {
+		return errors.New("TEST")
+	}
+	return nil
+}
+
+func (lc *lifecycleComponent) OnReady(ctx context.Context) error {
+	lc.rec.record("component-ready")
+	if lc.failReady {
+		return errors.New("TEST")
+	}
+	return nil
+}
+
+func (lc *lifecycleComponent) OnStop(ctx context.Context) error {
+	lc.rec.record("component-stop")
+	return nil
+}
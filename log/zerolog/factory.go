@@ -6,21 +6,50 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"time"
 
-	"github.com/rs/zerolog"
+	"github.com/mantzas/patron/errors"
 	"github.com/mantzas/patron/log"
+	"github.com/rs/zerolog"
+)
+
+var (
+	lvlMu    sync.Mutex
+	curLevel = log.DebugLevel
 )
 
 // Create creates a zerolog factory with default settings.
+// The level is applied globally in order to be changeable at runtime with SetLevel.
 func Create(lvl log.Level) log.FactoryFunc {
 	zerolog.LevelFieldName = "lvl"
 	zerolog.MessageFieldName = "msg"
 	zerolog.TimeFieldFormat = time.RFC3339Nano
+	_ = SetLevel(lvl)
 	zl := zerolog.New(os.Stdout).With().Timestamp().Logger().Hook(sourceHook{skip: 7})
 	return func(f map[string]interface{}) log.Logger {
-		return NewLogger(&zl, lvl, f)
+		return NewLogger(&zl, log.DebugLevel, f)
+	}
+}
+
+// SetLevel changes the level of all loggers created by the factory at runtime.
+func SetLevel(lvl log.Level) error {
+	zl, ok := levelMap[lvl]
+	if !ok {
+		return errors.Errorf("log level %s is not valid", lvl)
 	}
+	lvlMu.Lock()
+	defer lvlMu.Unlock()
+	zerolog.SetGlobalLevel(zl)
+	curLevel = lvl
+	return nil
+}
+
+// Level returns the current level of the loggers created by the factory.
+func Level() log.Level {
+	lvlMu.Lock()
+	defer lvlMu.Unlock()
+	return curLevel
 }
 
 type sourceHook struct {
@@ -9,8 +9,8 @@ import (
 )
 
 func TestDefaultFactory(t *testing.T) {
-	f := Create(log.InfoLevel)
-	assert.NotNil(t, f)
+	assert.NotNil(t, Create(log.InfoLevel))
+	assert.NoError(t, SetLevel(log.DebugLevel))
 }
 
 func Test_getSource(t *testing.T) {
@@ -35,9 +35,20 @@ func Test_sourceFields(t *testing.T) {
 	assert.Equal(t, "zerolog/factory_test.go:32", src)
 }
 
+func TestSetLevel(t *testing.T) {
+	defer func() { assert.NoError(t, SetLevel(log.DebugLevel)) }()
+	Create(log.InfoLevel)
+	assert.Equal(t, log.InfoLevel, Level())
+	assert.NoError(t, SetLevel(log.WarnLevel))
+	assert.Equal(t, log.WarnLevel, Level())
+	assert.Error(t, SetLevel("XXX"))
+	assert.Equal(t, log.WarnLevel, Level())
+}
+
 var l log.Logger
 
 func Benchmark_Create(b *testing.B) {
+	defer func() { _ = SetLevel(log.DebugLevel) }()
 	f := Create(log.InfoLevel)
 	fld := map[string]interface{}{
 		"key1": "val1",
@@ -0,0 +1,143 @@
+package patron
+
+import (
+	"context"
+	"time"
+
+	"github.com/mantzas/patron/info"
+	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/log/zerolog"
+	"github.com/mantzas/patron/sync"
+	"github.com/mantzas/patron/sync/http"
+)
+
+const logLevelPath = "/admin/loglevel"
+
+var levelOrder = map[log.Level]int{
+	log.DebugLevel: 0,
+	log.InfoLevel:  1,
+	log.WarnLevel:  2,
+	log.ErrorLevel: 3,
+	log.FatalLevel: 4,
+	log.PanicLevel: 5,
+	log.NoLevel:    6,
+}
+
+// logLevelRequest definition of the request for changing the log level.
+// The optional TTL, e.g. "10m", reverts the level to the configured one after it expires.
+type logLevelRequest struct {
+	Level string `json:"level"`
+	TTL   string `json:"ttl,omitempty"`
+}
+
+// logLevelResponse definition of the response of the log level endpoint.
+type logLevelResponse struct {
+	Level    string `json:"level"`
+	RevertAt string `json:"revert_at,omitempty"`
+}
+
+func (s *Service) getLogLevelProcessor(_ context.Context, _ *sync.Request) (*sync.Response, error) {
+	s.lvlMu.Lock()
+	defer s.lvlMu.Unlock()
+	return sync.NewResponse(s.logLevelResponse()), nil
+}
+
+func (s *Service) putLogLevelProcessor(_ context.Context, req *sync.Request) (*sync.Response, error) {
+	var lr logLevelRequest
+	err := req.Decode(&lr)
+	if err != nil {
+		return nil, http.NewValidationErrorWithPayload("failed to decode request")
+	}
+	if lr.Level == "" {
+		return nil, http.NewValidationErrorWithPayload("log level is required")
+	}
+	lvl := log.Level(lr.Level)
+	if _, ok := levelOrder[lvl]; !ok {
+		return nil, http.NewValidationErrorWithPayload("log level is not valid")
+	}
+	var ttl time.Duration
+	if lr.TTL != "" {
+		ttl, err = time.ParseDuration(lr.TTL)
+		if err != nil || ttl <= 0 {
+			return nil, http.NewValidationErrorWithPayload("ttl is not valid")
+		}
+	}
+
+	s.lvlMu.Lock()
+	defer s.lvlMu.Unlock()
+	err = s.setLogLevel(lvl, "admin endpoint")
+	if err != nil {
+		return nil, err
+	}
+	s.lvlGen++
+	if s.lvlTimer != nil {
+		s.lvlTimer.Stop()
+		s.lvlTimer = nil
+		s.lvlRevertAt = time.Time{}
+	}
+	if ttl > 0 {
+		gen := s.lvlGen
+		s.lvlRevertAt = time.Now().Add(ttl)
+		s.lvlTimer = time.AfterFunc(ttl, func() { s.revertLogLevel(gen) })
+	}
+	info.UpsertConfig("log_level_revert_at", s.revertAt())
+	return sync.NewResponse(s.logLevelResponse()), nil
+}
+
+// revertLogLevel reverts the log level to the configured one after the TTL of a change expired,
+// unless the level has been changed again in the meantime.
+func (s *Service) revertLogLevel(gen uint64) {
+	s.lvlMu.Lock()
+	defer s.lvlMu.Unlock()
+	if gen != s.lvlGen {
+		return
+	}
+	s.lvlTimer = nil
+	s.lvlRevertAt = time.Time{}
+	info.UpsertConfig("log_level_revert_at", s.revertAt())
+	err := s.setLogLevel(log.Level(s.configuredLogLevel()), "ttl expiry")
+	if err != nil {
+		log.Errorf("failed to revert log level: %v", err)
+	}
+}
+
+// setLogLevel changes the log level and audits the change, logging it with the more verbose of the two levels.
+func (s *Service) setLogLevel(lvl log.Level, src string) error {
+	old := zerolog.Level()
+	if levelOrder[lvl] > levelOrder[old] {
+		log.Infof("log level changed from %s to %s by %s", old, lvl, src)
+	}
+	err := zerolog.SetLevel(lvl)
+	if err != nil {
+		return err
+	}
+	if levelOrder[lvl] <= levelOrder[old] {
+		log.Infof("log level changed from %s to %s by %s", old, lvl, src)
+	}
+	info.UpsertConfig("log_level", string(lvl))
+	return nil
+}
+
+func (s *Service) configuredLogLevel() string {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	return s.cfg.LogLevel
+}
+
+func (s *Service) revertAt() string {
+	if s.lvlRevertAt.IsZero() {
+		return ""
+	}
+	return s.lvlRevertAt.Format(time.RFC3339)
+}
+
+func (s *Service) logLevelResponse() logLevelResponse {
+	return logLevelResponse{Level: string(zerolog.Level()), RevertAt: s.revertAt()}
+}
+
+func (s *Service) logLevelRoutes() []http.Route {
+	return []http.Route{
+		http.NewAuthGetRoute(logLevelPath, s.getLogLevelProcessor, false, s.mgmtAuth),
+		http.NewAuthPutRoute(logLevelPath, s.putLogLevelProcessor, false, s.mgmtAuth),
+	}
+}
@@ -0,0 +1,103 @@
+package patron
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/encoding/json"
+	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/log/zerolog"
+	"github.com/mantzas/patron/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_LogLevel(t *testing.T) {
+	defer func() { assert.NoError(t, zerolog.SetLevel(log.InfoLevel)) }()
+	s, err := New("test", "")
+	assert.NoError(t, err)
+
+	rsp, err := s.getLogLevelProcessor(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, logLevelResponse{Level: "info"}, rsp.Payload)
+
+	rsp, err = s.putLogLevelProcessor(context.Background(), logLevelReq(`{"level":"debug"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, logLevelResponse{Level: "debug"}, rsp.Payload)
+	assert.Equal(t, log.DebugLevel, zerolog.Level())
+}
+
+func TestService_LogLevel_TTL(t *testing.T) {
+	defer func() { assert.NoError(t, zerolog.SetLevel(log.InfoLevel)) }()
+	s, err := New("test", "")
+	assert.NoError(t, err)
+
+	rsp, err := s.putLogLevelProcessor(context.Background(), logLevelReq(`{"level":"error","ttl":"50ms"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "error", rsp.Payload.(logLevelResponse).Level)
+	assert.NotEmpty(t, rsp.Payload.(logLevelResponse).RevertAt)
+	assert.Equal(t, log.ErrorLevel, zerolog.Level())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, log.InfoLevel, zerolog.Level())
+	rsp, err = s.getLogLevelProcessor(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, logLevelResponse{Level: "info"}, rsp.Payload)
+}
+
+func TestService_LogLevel_TTL_Overridden(t *testing.T) {
+	defer func() { assert.NoError(t, zerolog.SetLevel(log.InfoLevel)) }()
+	s, err := New("test", "")
+	assert.NoError(t, err)
+
+	_, err = s.putLogLevelProcessor(context.Background(), logLevelReq(`{"level":"error","ttl":"50ms"}`))
+	assert.NoError(t, err)
+	_, err = s.putLogLevelProcessor(context.Background(), logLevelReq(`{"level":"warn"}`))
+	assert.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, log.WarnLevel, zerolog.Level())
+}
+
+func TestService_LogLevel_TTL_FiredAfterOverride(t *testing.T) {
+	defer func() { assert.NoError(t, zerolog.SetLevel(log.InfoLevel)) }()
+	s, err := New("test", "")
+	assert.NoError(t, err)
+
+	_, err = s.putLogLevelProcessor(context.Background(), logLevelReq(`{"level":"error","ttl":"1h"}`))
+	assert.NoError(t, err)
+	gen := s.lvlGen
+	rsp, err := s.putLogLevelProcessor(context.Background(), logLevelReq(`{"level":"warn","ttl":"1h"}`))
+	assert.NoError(t, err)
+	// a revert of the first change, whose timer fired before it was stopped, is ignored
+	s.revertLogLevel(gen)
+	assert.Equal(t, log.WarnLevel, zerolog.Level())
+	assert.NotNil(t, s.lvlTimer)
+	assert.Equal(t, rsp.Payload.(logLevelResponse).RevertAt, s.revertAt())
+	s.lvlTimer.Stop()
+}
+
+func TestService_LogLevel_Invalid(t *testing.T) {
+	s, err := New("test", "")
+	assert.NoError(t, err)
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "invalid body", body: `XXX`},
+		{name: "missing level", body: `{}`},
+		{name: "invalid level", body: `{"level":"XXX"}`},
+		{name: "invalid ttl", body: `{"level":"debug","ttl":"XXX"}`},
+		{name: "negative ttl", body: `{"level":"debug","ttl":"-1m"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rsp, err := s.putLogLevelProcessor(context.Background(), logLevelReq(tt.body))
+			assert.Error(t, err)
+			assert.Nil(t, rsp)
+		})
+	}
+}
+
+func logLevelReq(body string) *sync.Request {
+	return sync.NewRequest(nil, strings.NewReader(body), nil, json.Decode)
+}
@@ -1,10 +1,16 @@
 package patron
 
 import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
 	"github.com/mantzas/patron/errors"
 	"github.com/mantzas/patron/info"
 	"github.com/mantzas/patron/log"
 	"github.com/mantzas/patron/sync/http"
+	"github.com/mantzas/patron/sync/http/auth"
 )
 
 // OptionFunc definition for configuring the service in a functional way.
@@ -46,6 +52,26 @@ func Components(cc ...Component) OptionFunc {
 	}
 }
 
+// SupervisedComponents option for adding additional components to the service,
+// which are supervised with the provided supervision when exiting.
+func SupervisedComponents(sup Supervision, cc ...Component) OptionFunc {
+	return func(s *Service) error {
+		if len(cc) == 0 || cc[0] == nil {
+			return errors.New("components are required")
+		}
+		err := sup.validate()
+		if err != nil {
+			return err
+		}
+		for i := range cc {
+			s.sups[len(s.cps)+i] = sup
+		}
+		s.cps = append(s.cps, cc...)
+		log.Infof("supervised components with %s are set", sup.Policy)
+		return nil
+	}
+}
+
 // Docs option for adding additional documentation to the service info response.
 func Docs(file string) OptionFunc {
 	return func(s *Service) error {
@@ -57,3 +83,129 @@ func Docs(file string) OptionFunc {
 		return nil
 	}
 }
+
+// ConfigFile option for loading the service configuration from a YAML or JSON file, along with the environment
+// variables, which take precedence. The file is read again when the configuration is reloaded.
+func ConfigFile(file string) OptionFunc {
+	return func(s *Service) error {
+		if file == "" {
+			return errors.New("config file is required")
+		}
+		s.cfgFile = file
+		log.Infof("config file is set to %s", file)
+		return nil
+	}
+}
+
+// ShutdownTimeout option for setting the total grace period the service has to stop all components.
+func ShutdownTimeout(d time.Duration) OptionFunc {
+	return func(s *Service) error {
+		if d <= 0 {
+			return errors.New("shutdown timeout must be positive")
+		}
+		s.shutdownTimeout = d
+		log.Infof("shutdown timeout is set to %v", d)
+		return nil
+	}
+}
+
+// ComponentShutdownTimeout option for setting the time each component has to stop
+// before being reported as having missed its deadline.
+func ComponentShutdownTimeout(d time.Duration) OptionFunc {
+	return func(s *Service) error {
+		if d <= 0 {
+			return errors.New("component shutdown timeout must be positive")
+		}
+		s.cmpShutdownTimeout = d
+		log.Infof("component shutdown timeout is set to %v", d)
+		return nil
+	}
+}
+
+// OnStart option for adding a hook which is called before the components are run.
+func OnStart(h func(ctx context.Context) error) OptionFunc {
+	return func(s *Service) error {
+		if h == nil {
+			return errors.New("start hook is required")
+		}
+		s.startHooks = append(s.startHooks, h)
+		log.Info("start hook is set")
+		return nil
+	}
+}
+
+// OnReady option for adding a hook which is called after all components are run.
+func OnReady(h func(ctx context.Context) error) OptionFunc {
+	return func(s *Service) error {
+		if h == nil {
+			return errors.New("ready hook is required")
+		}
+		s.readyHooks = append(s.readyHooks, h)
+		log.Info("ready hook is set")
+		return nil
+	}
+}
+
+// OnStop option for adding a hook which is called after all components have been stopped.
+func OnStop(h func(ctx context.Context) error) OptionFunc {
+	return func(s *Service) error {
+		if h == nil {
+			return errors.New("stop hook is required")
+		}
+		s.stopHooks = append(s.stopHooks, h)
+		log.Info("stop hook is set")
+		return nil
+	}
+}
+
+// OnReload option for adding a hook which is called after the service configuration has been reloaded
+// via a SIGHUP signal or the reload endpoint of the default HTTP component.
+func OnReload(h func(ctx context.Context) error) OptionFunc {
+	return func(s *Service) error {
+		if h == nil {
+			return errors.New("reload hook is required")
+		}
+		s.reloadHooks = append(s.reloadHooks, h)
+		log.Info("reload hook is set")
+		return nil
+	}
+}
+
+// ManagementListener option for hosting the management endpoints (health, metrics, info, profiling, reload and log level)
+// on a dedicated HTTP component listening on the provided bind address and port.
+// An empty bind address listens on all interfaces.
+func ManagementListener(bind string, port int) OptionFunc {
+	return func(s *Service) error {
+		if bind != "" && net.ParseIP(bind) == nil {
+			return errors.New("invalid management bind address")
+		}
+		if port <= 0 || port > 65535 {
+			return errors.New("invalid management port")
+		}
+		s.mgmtBind = bind
+		s.mgmtPort = port
+		log.Infof("management listener is set to %s", net.JoinHostPort(bind, strconv.Itoa(port)))
+		return nil
+	}
+}
+
+// DisableProfiling option for not exposing the profiling endpoints.
+func DisableProfiling() OptionFunc {
+	return func(s *Service) error {
+		s.noProf = true
+		log.Info("profiling is disabled")
+		return nil
+	}
+}
+
+// ManagementAuth option for protecting the management endpoints, except the health check, with a authenticator.
+func ManagementAuth(a auth.Authenticator) OptionFunc {
+	return func(s *Service) error {
+		if a == nil {
+			return errors.New("authenticator is required")
+		}
+		s.mgmtAuth = a
+		log.Info("management authenticator is set")
+		return nil
+	}
+}
@@ -1,10 +1,14 @@
 package patron
 
 import (
+	"context"
+	nethttp "net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/mantzas/patron/sync/http"
+	"github.com/mantzas/patron/sync/http/auth"
 )
 
 func TestRoutes(t *testing.T) {
@@ -112,3 +116,190 @@ func TestDocs(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigFile(t *testing.T) {
+	s := Service{}
+	assert.Error(t, ConfigFile("")(&s))
+	assert.NoError(t, ConfigFile("config.yaml")(&s))
+	assert.Equal(t, "config.yaml", s.cfgFile)
+	_, err := New("test", "", ConfigFile("missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "success", timeout: time.Second, wantErr: false},
+		{name: "failure due to zero timeout", timeout: 0, wantErr: true},
+		{name: "failure due to negative timeout", timeout: -time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New("test", "1.0.0")
+			assert.NoError(t, err)
+			err = ShutdownTimeout(tt.timeout)(s)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.timeout, s.shutdownTimeout)
+			}
+		})
+	}
+}
+
+func TestComponentShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "success", timeout: time.Second, wantErr: false},
+		{name: "failure due to zero timeout", timeout: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New("test", "1.0.0")
+			assert.NoError(t, err)
+			err = ComponentShutdownTimeout(tt.timeout)(s)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.timeout, s.cmpShutdownTimeout)
+			}
+		})
+	}
+}
+
+func TestHooks(t *testing.T) {
+	h := func(context.Context) error { return nil }
+	tests := []struct {
+		name    string
+		opt     OptionFunc
+		wantErr bool
+	}{
+		{name: "start hook success", opt: OnStart(h), wantErr: false},
+		{name: "start hook nil", opt: OnStart(nil), wantErr: true},
+		{name: "ready hook success", opt: OnReady(h), wantErr: false},
+		{name: "ready hook nil", opt: OnReady(nil), wantErr: true},
+		{name: "stop hook success", opt: OnStop(h), wantErr: false},
+		{name: "stop hook nil", opt: OnStop(nil), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New("test", "1.0.0")
+			assert.NoError(t, err)
+			err = tt.opt(s)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSupervisedComponents(t *testing.T) {
+	tests := []struct {
+		name    string
+		sup     Supervision
+		c       Component
+		wantErr bool
+	}{
+		{name: "success", sup: Supervision{Policy: RestartPolicy, Restarts: 3, Backoff: time.Second}, c: &testComponent{}, wantErr: false},
+		{name: "failure due to nil components", sup: Supervision{}, c: nil, wantErr: true},
+		{name: "failure due to invalid policy", sup: Supervision{Policy: 3}, c: &testComponent{}, wantErr: true},
+		{name: "failure due to invalid restarts", sup: Supervision{Policy: RestartPolicy, Restarts: -1}, c: &testComponent{}, wantErr: true},
+		{name: "failure due to invalid backoff", sup: Supervision{Policy: RestartPolicy, Backoff: -1}, c: &testComponent{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New("test", "1.0.0")
+			assert.NoError(t, err)
+			err = SupervisedComponents(tt.sup, tt.c)(s)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.sup, s.sups[1])
+			}
+		})
+	}
+}
+
+func TestOnReload(t *testing.T) {
+	s, err := New("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Error(t, OnReload(nil)(s))
+	assert.NoError(t, OnReload(func(context.Context) error { return nil })(s))
+	assert.Len(t, s.reloadHooks, 1)
+}
+
+type mockAuthenticator struct{}
+
+func (mockAuthenticator) Authenticate(req *nethttp.Request) (bool, error) {
+	return false, nil
+}
+
+func TestManagementListener(t *testing.T) {
+	tests := []struct {
+		name    string
+		bind    string
+		port    int
+		wantErr bool
+	}{
+		{name: "success", bind: "127.0.0.1", port: 50001, wantErr: false},
+		{name: "success with empty bind address", bind: "", port: 50001, wantErr: false},
+		{name: "failure due to invalid bind address", bind: "localhost", port: 50001, wantErr: true},
+		{name: "failure due to invalid port", bind: "127.0.0.1", port: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New("test", "1.0.0")
+			assert.NoError(t, err)
+			err = ManagementListener(tt.bind, tt.port)(s)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.bind, s.mgmtBind)
+				assert.Equal(t, tt.port, s.mgmtPort)
+			}
+		})
+	}
+}
+
+func TestDisableProfiling(t *testing.T) {
+	s, err := New("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.NoError(t, DisableProfiling()(s))
+	assert.True(t, s.noProf)
+}
+
+func TestManagementAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    auth.Authenticator
+		wantErr bool
+	}{
+		{name: "success", auth: mockAuthenticator{}, wantErr: false},
+		{name: "failure due to missing authenticator", auth: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New("test", "1.0.0")
+			assert.NoError(t, err)
+			err = ManagementAuth(tt.auth)(s)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.auth, s.mgmtAuth)
+			}
+		})
+	}
+}
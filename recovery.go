@@ -0,0 +1,38 @@
+package patron
+
+import (
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var componentPanics *prometheus.CounterVec
+
+func init() {
+	componentPanics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "service",
+			Subsystem: "component",
+			Name:      "panics",
+			Help:      "Component panics, classified by component",
+		},
+		[]string{"component"},
+	)
+	prometheus.MustRegister(componentPanics)
+}
+
+// runComponent runs the component converting a panic into a error, so that it is handled
+// by the supervision policy of the component instead of crashing the service.
+func (r *runner) runComponent() (err error) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+		p := errors.FromPanic(rec)
+		componentPanics.WithLabelValues(r.name).Inc()
+		log.Sub(map[string]interface{}{"component": r.name}).Errorf("component %s recovered from panic: %v\n%s", r.name, p.Value, p.Stack)
+		err = p
+	}()
+	return r.cp.Run(r.ctx)
+}
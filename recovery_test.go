@@ -0,0 +1,67 @@
+package patron
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type panickingComponent struct {
+	failingComponent
+}
+
+func (pc *panickingComponent) Run(ctx context.Context) error {
+	pc.Lock()
+	pc.runs++
+	fail := pc.runs <= pc.failures
+	pc.Unlock()
+	if fail {
+		panic("component panic")
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestServer_Run_RecoversPanic(t *testing.T) {
+	tests := []struct {
+		name    string
+		sup     Supervision
+		wantErr bool
+	}{
+		{"fatal policy", Supervision{Policy: FatalPolicy}, true},
+		{"restart policy", Supervision{Policy: RestartPolicy, Restarts: 1, Backoff: time.Millisecond}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := &panickingComponent{failingComponent{failures: 1}}
+			s, err := New("test", "", SupervisedComponents(tt.sup, cp))
+			assert.NoError(t, err)
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				s.termSig <- syscall.SIGTERM
+			}()
+			err = s.Run()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "panic: component panic")
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, cp.getRuns())
+			}
+		})
+	}
+}
+
+func TestRunner_runComponent(t *testing.T) {
+	cp := &panickingComponent{failingComponent{failures: 1}}
+	r := newRunner("panicking[0]", cp, Supervision{}, &componentStatus{})
+	err := r.runComponent()
+	p, ok := err.(*errors.Panic)
+	assert.True(t, ok)
+	assert.Equal(t, "component panic", p.Value)
+	assert.NotEmpty(t, p.Stack)
+}
@@ -61,6 +61,13 @@ type Setting struct {
 	MaxRetryExecutionThreshold uint
 }
 
+func (s Setting) validate() error {
+	if s.MaxRetryExecutionThreshold < s.RetrySuccessThreshold {
+		return errors.New("max retry has to be greater than the retry threshold")
+	}
+	return nil
+}
+
 // Action function to execute in circuit breaker.
 type Action func() (interface{}, error)
 
@@ -83,8 +90,9 @@ func New(name string, s Setting) (*CircuitBreaker, error) {
 		return nil, errors.New("name is required")
 	}
 
-	if s.MaxRetryExecutionThreshold < s.RetrySuccessThreshold {
-		return nil, errors.New("max retry has to be greater than the retry threshold")
+	err := s.validate()
+	if err != nil {
+		return nil, err
 	}
 
 	return &CircuitBreaker{
@@ -98,6 +106,19 @@ func New(name string, s Setting) (*CircuitBreaker, error) {
 	}, nil
 }
 
+// UpdateSetting replaces the setting of the circuit breaker at runtime, e.g. when the configuration is reloaded.
+// The new setting applies to the next state transition.
+func (cb *CircuitBreaker) UpdateSetting(s Setting) error {
+	err := s.validate()
+	if err != nil {
+		return err
+	}
+	cb.Lock()
+	defer cb.Unlock()
+	cb.set = s
+	return nil
+}
+
 func (cb *CircuitBreaker) isHalfOpen() bool {
 	cb.RLock()
 	defer cb.RUnlock()
@@ -200,3 +200,13 @@ func testSuccessAction() (interface{}, error) {
 func testFailureAction() (interface{}, error) {
 	return "", errors.New("Test error")
 }
+
+func TestCircuitBreaker_UpdateSetting(t *testing.T) {
+	cb, err := New("test", Setting{FailureThreshold: 1, RetrySuccessThreshold: 1, MaxRetryExecutionThreshold: 1})
+	assert.NoError(t, err)
+	s := Setting{FailureThreshold: 5, RetryTimeout: time.Second, RetrySuccessThreshold: 2, MaxRetryExecutionThreshold: 3}
+	assert.NoError(t, cb.UpdateSetting(s))
+	assert.Equal(t, s, cb.set)
+	assert.Error(t, cb.UpdateSetting(Setting{RetrySuccessThreshold: 2, MaxRetryExecutionThreshold: 1}))
+	assert.Equal(t, s, cb.set)
+}
@@ -0,0 +1,74 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Infinite retries, which are only stopped by the context being done.
+const Infinite = -1
+
+// Backoff policy which grows the wait exponentially with every attempt, up to a max wait.
+// Jitter randomizes each wait by the given ratio, e.g. 0.2 results in a wait between 80% and 120%
+// of the exponential wait, in order to spread the retries of concurrent callers.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// Validate checks the backoff policy.
+func (b Backoff) Validate() error {
+	if b.Initial < 0 {
+		return errors.New("initial wait should be zero or positive")
+	}
+	if b.Max < 0 {
+		return errors.New("max wait should be zero or positive")
+	}
+	if b.Multiplier != 0 && b.Multiplier < 1 {
+		return errors.New("multiplier should be greater or equal to one")
+	}
+	if b.Jitter < 0 || b.Jitter > 1 {
+		return errors.New("jitter should be between zero and one")
+	}
+	return nil
+}
+
+// Wait returns the wait before the given retry, starting from zero.
+// The multiplier defaults to 2 and a zero max wait means no limit.
+func (b Backoff) Wait(retry int) time.Duration {
+	m := b.Multiplier
+	if m == 0 {
+		m = 2
+	}
+	w := float64(b.Initial) * math.Pow(m, float64(retry))
+	if b.Max > 0 && w > float64(b.Max) {
+		w = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		w = w * (1 - b.Jitter + 2*b.Jitter*rand.Float64())
+	}
+	if w >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(w)
+}
+
+// Sleep waits for the given duration, returning early with the error of the context when it is done.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       Backoff
+		wantErr bool
+	}{
+		{name: "success", b: Backoff{Initial: time.Second, Max: time.Minute, Multiplier: 1.5, Jitter: 0.2}, wantErr: false},
+		{name: "success with defaults", b: Backoff{}, wantErr: false},
+		{name: "invalid initial", b: Backoff{Initial: -1}, wantErr: true},
+		{name: "invalid max", b: Backoff{Max: -1}, wantErr: true},
+		{name: "invalid multiplier", b: Backoff{Multiplier: 0.5}, wantErr: true},
+		{name: "invalid jitter", b: Backoff{Jitter: 1.5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.b.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBackoff_Wait(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	assert.Equal(t, 100*time.Millisecond, b.Wait(0))
+	assert.Equal(t, 200*time.Millisecond, b.Wait(1))
+	assert.Equal(t, 800*time.Millisecond, b.Wait(3))
+	assert.Equal(t, time.Second, b.Wait(4))
+	assert.Equal(t, time.Second, b.Wait(1000))
+	b = Backoff{Initial: 100 * time.Millisecond, Multiplier: 3, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		w := b.Wait(1)
+		assert.True(t, w >= 150*time.Millisecond && w <= 450*time.Millisecond)
+	}
+}
+
+func TestSleep(t *testing.T) {
+	assert.NoError(t, Sleep(context.Background(), time.Millisecond))
+	assert.NoError(t, Sleep(context.Background(), 0))
+	ctx, cnl := context.WithCancel(context.Background())
+	cnl()
+	assert.Error(t, Sleep(ctx, time.Minute))
+}
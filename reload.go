@@ -0,0 +1,80 @@
+package patron
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mantzas/patron/info"
+	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/log/zerolog"
+	"github.com/mantzas/patron/sync"
+	patronhttp "github.com/mantzas/patron/sync/http"
+	"github.com/mantzas/patron/trace"
+)
+
+const reloadPath = "/admin/reload"
+
+// reload re-reads the service configuration, from the environment and the configuration file, applies the changes that are supported at runtime
+// and notifies the reload listeners. The result is published to the service info.
+func (s *Service) reload(ctx context.Context) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	err := s.applyReload(ctx)
+	info.UpdateReload(time.Now(), err)
+	if err != nil {
+		log.Errorf("failed to reload configuration: %v", err)
+		return err
+	}
+	log.Info("configuration reloaded")
+	return nil
+}
+
+func (s *Service) applyReload(ctx context.Context) error {
+	cfg, err := loadConfig(s.cfgFile)
+	if err != nil {
+		return err
+	}
+
+	if cfg.LogLevel != s.cfg.LogLevel {
+		err = zerolog.SetLevel(log.Level(cfg.LogLevel))
+		if err != nil {
+			return err
+		}
+		log.Infof("log level changed from %s to %s", s.cfg.LogLevel, cfg.LogLevel)
+	}
+
+	if cfg.JaegerAgent != s.cfg.JaegerAgent ||
+		cfg.JaegerSamplerType != s.cfg.JaegerSamplerType ||
+		cfg.JaegerSamplerParam != s.cfg.JaegerSamplerParam {
+		err = trace.Close()
+		if err != nil {
+			log.Errorf("failed to close trace %v", err)
+		}
+		err = trace.Setup(s.name, s.version, cfg.JaegerAgent, cfg.JaegerSamplerType, cfg.JaegerSamplerParam)
+		if err != nil {
+			return err
+		}
+		log.Infof("tracing changed to %s, %s with param %v", cfg.JaegerAgent, cfg.JaegerSamplerType, cfg.JaegerSamplerParam)
+	}
+
+	if cfg.HTTPPort != s.cfg.HTTPPort {
+		log.Warnf("changing the HTTP default port from %d to %d requires a restart", s.cfg.HTTPPort, cfg.HTTPPort)
+		cfg.HTTPPort = s.cfg.HTTPPort
+	}
+	s.cfg = cfg
+
+	return runHooks(ctx, "reload", s.reloadHooks)
+}
+
+func (s *Service) reloadProcessor(ctx context.Context, _ *sync.Request) (*sync.Response, error) {
+	err := s.reload(ctx)
+	if err != nil {
+		return nil, patronhttp.NewErrorWithCodeAndPayload(http.StatusInternalServerError, map[string]string{"result": "failure", "error": err.Error()})
+	}
+	return sync.NewResponse(map[string]string{"result": "success"}), nil
+}
+
+func (s *Service) reloadRoute() patronhttp.Route {
+	return patronhttp.NewAuthPostRoute(reloadPath, s.reloadProcessor, false, s.mgmtAuth)
+}
@@ -0,0 +1,100 @@
+package patron
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/info"
+	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/log/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Reload(t *testing.T) {
+	defer func() { assert.NoError(t, zerolog.SetLevel(log.InfoLevel)) }()
+	reloaded := 0
+	s, err := New("test", "", OnReload(func(context.Context) error {
+		reloaded++
+		return nil
+	}))
+	assert.NoError(t, err)
+	assert.NoError(t, os.Setenv("PATRON_LOG_LEVEL", "warn"))
+	defer func() { assert.NoError(t, os.Unsetenv("PATRON_LOG_LEVEL")) }()
+	assert.NoError(t, s.reload(context.Background()))
+	assert.Equal(t, 1, reloaded)
+	assert.Equal(t, log.WarnLevel, zerolog.Level())
+	assert.Equal(t, "warn", s.cfg.LogLevel)
+	b, err := info.Marshal()
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"result":"success"`)
+}
+
+func TestService_Reload_ConfigFile(t *testing.T) {
+	defer func() { assert.NoError(t, zerolog.SetLevel(log.InfoLevel)) }()
+	f, err := ioutil.TempFile("", "patron-*.yaml")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.Remove(f.Name())) }()
+	assert.NoError(t, f.Close())
+	assert.NoError(t, ioutil.WriteFile(f.Name(), []byte("log_level: info\n"), 0600))
+	s, err := New("test", "", ConfigFile(f.Name()))
+	assert.NoError(t, err)
+	assert.Equal(t, "info", s.cfg.LogLevel)
+	assert.NoError(t, ioutil.WriteFile(f.Name(), []byte("log_level: error\njaeger-agent-sampler-param: 0.5\n"), 0600))
+	assert.NoError(t, s.reload(context.Background()))
+	assert.Equal(t, log.ErrorLevel, zerolog.Level())
+	assert.Equal(t, 0.5, s.cfg.JaegerSamplerParam)
+}
+
+func TestService_Reload_Failure(t *testing.T) {
+	s, err := New("test", "", OnReload(func(context.Context) error {
+		return errors.New("TEST")
+	}))
+	assert.NoError(t, err)
+	assert.Error(t, s.reload(context.Background()))
+	b, err := info.Marshal()
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"result":"failure"`)
+
+	assert.NoError(t, os.Setenv("PATRON_HTTP_DEFAULT_PORT", "XXX"))
+	defer func() { assert.NoError(t, os.Unsetenv("PATRON_HTTP_DEFAULT_PORT")) }()
+	assert.Error(t, s.reload(context.Background()))
+}
+
+func TestService_Reload_Processor(t *testing.T) {
+	fail := false
+	s, err := New("test", "", OnReload(func(context.Context) error {
+		if fail {
+			return errors.New("TEST")
+		}
+		return nil
+	}))
+	assert.NoError(t, err)
+	rsp, err := s.reloadProcessor(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"result": "success"}, rsp.Payload)
+	fail = true
+	rsp, err = s.reloadProcessor(context.Background(), nil)
+	assert.Error(t, err)
+	assert.Nil(t, rsp)
+}
+
+func TestServer_Run_ReloadOnHangUp(t *testing.T) {
+	chReloaded := make(chan struct{})
+	s, err := New("test", "", OnReload(func(context.Context) error {
+		close(chReloaded)
+		return nil
+	}))
+	assert.NoError(t, err)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.hupSig <- syscall.SIGHUP
+		<-chReloaded
+		s.termSig <- syscall.SIGTERM
+	}()
+	assert.NoError(t, s.Run())
+}
@@ -2,19 +2,25 @@ package patron
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/health"
 	"github.com/mantzas/patron/info"
 	"github.com/mantzas/patron/log"
 	"github.com/mantzas/patron/log/zerolog"
 	"github.com/mantzas/patron/sync/http"
+	"github.com/mantzas/patron/sync/http/auth"
 	"github.com/mantzas/patron/trace"
-	jaeger "github.com/uber/jaeger-client-go"
+)
+
+const (
+	defaultShutdownTimeout = 30 * time.Second
 )
 
 var logSetupOnce sync.Once
@@ -27,11 +33,37 @@ type Component interface {
 
 // Service is responsible for managing and setting up everything.
 // The service will start by default a HTTP component in order to host management endpoint.
+// When a management listener is set, the management endpoints are hosted by a dedicated HTTP component
+// and the default HTTP component hosts only the application routes.
 type Service struct {
-	cps     []Component
-	routes  []http.Route
-	hcf     http.HealthCheckFunc
-	termSig chan os.Signal
+	cps                []Component
+	routes             []http.Route
+	hcf                http.HealthCheckFunc
+	termSig            chan os.Signal
+	shutdownTimeout    time.Duration
+	cmpShutdownTimeout time.Duration
+	startHooks         []HookFunc
+	readyHooks         []HookFunc
+	stopHooks          []HookFunc
+	sups               map[int]Supervision
+	statuses           []*componentStatus
+	cfg                *serviceConfig
+	name               string
+	version            string
+	reloadHooks        []HookFunc
+	reloadMu           sync.Mutex
+	hupSig             chan os.Signal
+	lvlMu              sync.Mutex
+	lvlTimer           *time.Timer
+	lvlRevertAt        time.Time
+	lvlGen             uint64
+	mgmtBind           string
+	mgmtPort           int
+	mgmtAuth           auth.Authenticator
+	noProf             bool
+	cfgFile            string
+	readyMu            sync.Mutex
+	isReady            bool
 }
 
 // New creates a new named service and allows for customization through functional options.
@@ -47,31 +79,51 @@ func New(name, version string, oo ...OptionFunc) (*Service, error) {
 	info.UpdateName(name)
 	info.UpdateVersion(version)
 
-	s := Service{cps: []Component{}, hcf: http.DefaultHealthCheck, termSig: make(chan os.Signal, 1)}
+	s := Service{
+		cps:             []Component{},
+		hcf:             http.DefaultHealthCheck,
+		termSig:         make(chan os.Signal, 1),
+		hupSig:          make(chan os.Signal, 1),
+		name:            name,
+		version:         version,
+		shutdownTimeout: defaultShutdownTimeout,
+		sups:            make(map[int]Supervision),
+	}
 
-	err := Setup(name, version)
+	for _, o := range oo {
+		err := o(&s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := loadConfig(s.cfgFile)
 	if err != nil {
 		return nil, err
 	}
+	s.cfg = cfg
 
-	err = s.setupDefaultTracing(name, version)
+	err = setupLogging(name, version, log.Level(cfg.LogLevel))
 	if err != nil {
 		return nil, err
 	}
 
-	for _, o := range oo {
-		err = o(&s)
-		if err != nil {
-			return nil, err
-		}
+	err = s.setupDefaultTracing(name, version)
+	if err != nil {
+		return nil, err
 	}
 
-	httpCp, err := s.createHTTPComponent()
+	httpCps, err := s.createHTTPComponents()
 	if err != nil {
 		return nil, err
 	}
 
-	s.cps = append(s.cps, httpCp)
+	s.cps = append(s.cps, httpCps...)
+	err = health.Register("service", health.Readiness, s.readinessCheck)
+	if err != nil {
+		return nil, err
+	}
+	s.setupSupervision()
 	s.setupInfo()
 	s.setupTermSignal()
 	return &s, nil
@@ -79,17 +131,85 @@ func New(name, version string, oo ...OptionFunc) (*Service, error) {
 
 func (s *Service) setupTermSignal() {
 	signal.Notify(s.termSig, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(s.hupSig, syscall.SIGHUP)
+}
+
+func (s *Service) setupSupervision() {
+	s.statuses = make([]*componentStatus, len(s.cps))
+	for i := range s.cps {
+		s.statuses[i] = &componentStatus{policy: s.sups[i].Policy}
+	}
 }
 
 func (s *Service) setupInfo() {
-	for _, c := range s.cps {
-		info.AppendComponent(c.Info())
+	for i, c := range s.cps {
+		ci := c.Info()
+		if ci != nil {
+			ci["name"] = componentName(i, c)
+			ci["supervision"] = s.statuses[i]
+		}
+		info.AppendComponent(ci)
+	}
+}
+
+// healthCheck reports the service as unhealthy when the health check func reports healthy
+// but some component reports itself as down, and as degraded when some component
+// has been marked as degraded by its supervision policy.
+func (s *Service) healthCheck() http.HealthStatus {
+	hs := s.hcf()
+	if hs != http.Healthy {
+		return hs
+	}
+	degraded := false
+	for i, cp := range s.cps {
+		if s.statuses[i].isDegraded() {
+			degraded = true
+			continue
+		}
+		r, ok := cp.(health.Reporter)
+		if ok && r.Health() == health.Down {
+			return http.Unhealthy
+		}
+	}
+	if degraded {
+		return http.Degraded
+	}
+	return hs
+}
+
+func (s *Service) setReady(ready bool) {
+	s.readyMu.Lock()
+	defer s.readyMu.Unlock()
+	s.isReady = ready
+}
+
+// readinessCheck succeeds after the service got ready and until it starts shutting down,
+// as long as the health check func reports healthy or degraded.
+func (s *Service) readinessCheck(ctx context.Context) error {
+	s.readyMu.Lock()
+	ready := s.isReady
+	s.readyMu.Unlock()
+	if !ready {
+		return errors.New("service is not ready")
+	}
+	switch hs := s.healthCheck(); hs {
+	case http.Initializing:
+		return errors.New("service is initializing")
+	case http.Unhealthy:
+		return errors.New("service is unhealthy")
 	}
+	return nil
 }
 
 // Run starts up all service components and monitors for errors.
+// The start hooks are called before, and the ready hooks after, all components have been run.
+// The stop hooks are called after all components have been stopped.
+// While running, a SIGHUP signal reloads the configuration and notifies the reload hooks.
 // If a component returns a error the service is responsible for shutting down
 // all components and terminate itself.
+// Components are stopped one after the other in the order they were registered,
+// with the default HTTP components always stopped last, so that in-flight requests
+// are drained while the rest of the service is shutting down.
 func (s *Service) Run() error {
 	defer func() {
 		err := trace.Close()
@@ -97,44 +217,186 @@ func (s *Service) Run() error {
 			log.Errorf("failed to close trace %v", err)
 		}
 	}()
-	ctx, cnl := context.WithCancel(context.Background())
-	chErr := make(chan error, len(s.cps))
-	wg := sync.WaitGroup{}
-	wg.Add(len(s.cps))
-	for _, cp := range s.cps {
-		go func(c Component) {
-			defer wg.Done()
-			chErr <- c.Run(ctx)
-		}(cp)
+	ctx := context.Background()
+	err := runHooks(ctx, "start", s.startHooks)
+	if err != nil {
+		return err
 	}
 
-	var ee []error
-	select {
-	case sig := <-s.termSig:
-		log.Infof("signal %s received", sig.String())
-	case err := <-chErr:
-		log.Info("component error received")
-		ee = append(ee, err)
+	rr := make([]*runner, len(s.cps))
+	for i, cp := range s.cps {
+		rr[i] = newRunner(componentName(i, cp), cp, s.sups[i], s.statuses[i])
+	}
+
+	started, err := startComponents(ctx, rr)
+	if err != nil {
+		ee := []error{err}
+		for _, r := range rr[:started] {
+			ee = append(ee, stopComponent(ctx, r))
+		}
+		return errors.Aggregate(ee...)
+	}
+
+	chDone := make(chan *runner, len(rr))
+	for _, r := range rr {
+		go r.run(chDone)
+	}
+
+	err = s.ready(ctx, rr)
+	if err != nil {
+		log.Errorf("failed to get ready: %v", err)
+		return errors.Aggregate(err, s.shutdown(rr))
+	}
+	s.setReady(true)
+
+	s.wait(ctx, chDone)
+	return s.shutdown(rr)
+}
+
+// wait blocks until a termination signal is received or a component exits, reloading the configuration
+// whenever a hang up signal is received.
+func (s *Service) wait(ctx context.Context, chDone <-chan *runner) {
+	for {
+		select {
+		case sig := <-s.termSig:
+			log.Infof("signal %s received", sig.String())
+			return
+		case r := <-chDone:
+			if r.err != nil {
+				log.Infof("component %s error received", r.name)
+			} else {
+				log.Infof("component %s exited", r.name)
+			}
+			return
+		case sig := <-s.hupSig:
+			log.Infof("signal %s received, reloading configuration", sig.String())
+			_ = s.reload(ctx)
+		}
+	}
+}
+
+func (s *Service) ready(ctx context.Context, rr []*runner) error {
+	err := readyComponents(ctx, rr)
+	if err != nil {
+		return err
 	}
-	cnl()
+	return runHooks(ctx, "ready", s.readyHooks)
+}
 
-	wg.Wait()
-	close(chErr)
+func (s *Service) shutdown(rr []*runner) error {
+	s.setReady(false)
+	log.Infof("shutting down components with a grace period of %v", s.shutdownTimeout)
+	ctx, cnl := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cnl()
 
-	for err := range chErr {
-		ee = append(ee, err)
+	var ee []error
+	for _, r := range rr {
+		s.setDrainTimeout(ctx, r.cp)
+		err := r.stop(ctx, s.cmpShutdownTimeout)
+		if err != nil {
+			ee = append(ee, err)
+		}
+		err = stopComponent(ctx, r)
+		if err != nil {
+			ee = append(ee, err)
+		}
 	}
+	ee = append(ee, runHooks(ctx, "stop", s.stopHooks))
 	return errors.Aggregate(ee...)
 }
 
+// drainer is implemented by components, e.g. the HTTP component, which drain their in-flight work
+// within a timeout of their own when stopped.
+type drainer interface {
+	SetShutdownTimeout(d time.Duration)
+}
+
+// setDrainTimeout bounds the drain of the component by the remaining grace period, since the components
+// stopped before it may have used most of it, and by the per component timeout, if set.
+func (s *Service) setDrainTimeout(ctx context.Context, cp Component) {
+	dr, ok := cp.(drainer)
+	if !ok {
+		return
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	d := time.Until(deadline)
+	if s.cmpShutdownTimeout > 0 && s.cmpShutdownTimeout < d {
+		d = s.cmpShutdownTimeout
+	}
+	dr.SetShutdownTimeout(d)
+}
+
+type runner struct {
+	name   string
+	cp     Component
+	sup    Supervision
+	status *componentStatus
+	ctx    context.Context
+	cnl    context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func newRunner(name string, cp Component, sup Supervision, status *componentStatus) *runner {
+	ctx, cnl := context.WithCancel(context.Background())
+	return &runner{name: name, cp: cp, sup: sup, status: status, ctx: ctx, cnl: cnl, done: make(chan struct{})}
+}
+
+func (r *runner) run(ch chan<- *runner) {
+	notify, err := r.supervise()
+	r.err = err
+	close(r.done)
+	if notify {
+		ch <- r
+	}
+}
+
+// stop cancels the component and waits for it to return until either the per component
+// timeout, if set, or the deadline of the context expires.
+func (r *runner) stop(ctx context.Context, timeout time.Duration) error {
+	r.cnl()
+	if timeout > 0 {
+		var cnl context.CancelFunc
+		ctx, cnl = context.WithTimeout(ctx, timeout)
+		defer cnl()
+	}
+	select {
+	case <-r.done:
+		return r.err
+	default:
+	}
+	select {
+	case <-r.done:
+		log.Infof("component %s stopped", r.name)
+		return r.err
+	case <-ctx.Done():
+		log.Errorf("component %s did not stop in time", r.name)
+		return errors.Errorf("component %s missed its shutdown deadline", r.name)
+	}
+}
+
+// componentName returns a name for the component based on its type and registration index.
+func componentName(idx int, cp Component) string {
+	typ, ok := cp.Info()["type"]
+	if !ok {
+		typ = "component"
+	}
+	return fmt.Sprintf("%v[%d]", typ, idx)
+}
+
 // Setup set's up metrics and default logging.
 func Setup(name, version string) error {
-	lvl, ok := os.LookupEnv("PATRON_LOG_LEVEL")
-	if !ok {
-		lvl = string(log.InfoLevel)
+	cfg, err := loadConfig("")
+	if err != nil {
+		return err
 	}
+	return setupLogging(name, version, log.Level(cfg.LogLevel))
+}
 
-	info.UpsertConfig("log_level", lvl)
+func setupLogging(name, version string, lvl log.Level) error {
 	hostname, err := os.Hostname()
 	if err != nil {
 		return errors.Wrap(err, "failed to get hostname")
@@ -147,64 +409,82 @@ func Setup(name, version string) error {
 		"host": hostname,
 	}
 	logSetupOnce.Do(func() {
-		err = log.Setup(zerolog.Create(log.Level(lvl)), f)
+		err = log.Setup(zerolog.Create(lvl), f)
 	})
 
 	return err
 }
 
 func (s *Service) setupDefaultTracing(name, version string) error {
-	var err error
+	log.Infof("setting up default tracing %s, %s with param %v", s.cfg.JaegerAgent, s.cfg.JaegerSamplerType, s.cfg.JaegerSamplerParam)
+	return trace.Setup(name, version, s.cfg.JaegerAgent, s.cfg.JaegerSamplerType, s.cfg.JaegerSamplerParam)
+}
 
-	agent, ok := os.LookupEnv("PATRON_JAEGER_AGENT")
-	if !ok {
-		agent = "0.0.0.0:6831"
+// createHTTPComponents creates the default HTTP component, or, when a management listener is set,
+// a HTTP component for the application routes, if any, followed by a dedicated management HTTP component.
+func (s *Service) createHTTPComponents() ([]Component, error) {
+	if s.mgmtPort == 0 {
+		cp, err := s.createHTTPComponent(s.cfg.HTTPPort, s.routes)
+		if err != nil {
+			return nil, err
+		}
+		return []Component{cp}, nil
 	}
-	info.UpsertConfig("jaeger-agent", agent)
-	tp, ok := os.LookupEnv("PATRON_JAEGER_SAMPLER_TYPE")
-	if !ok {
-		tp = jaeger.SamplerTypeProbabilistic
+
+	if s.mgmtPort == s.cfg.HTTPPort {
+		return nil, errors.Errorf("management port %d is the same as the default HTTP port", s.mgmtPort)
 	}
-	info.UpsertConfig("jaeger-agent-sampler-type", tp)
-	var prmVal = 0.0
-	var prm = "0.0"
 
-	if prm, ok := os.LookupEnv("PATRON_JAEGER_SAMPLER_PARAM"); ok {
-		prmVal, err = strconv.ParseFloat(prm, 64)
+	var cc []Component
+	if len(s.routes) > 0 {
+		log.Infof("creating application HTTP component at port %d", s.cfg.HTTPPort)
+		cp, err := http.New(
+			http.Port(s.cfg.HTTPPort),
+			http.ShutdownTimeout(s.httpShutdownTimeout()),
+			http.Routes(s.routes),
+			http.DisableManagementRoutes(),
+		)
 		if err != nil {
-			return errors.Wrap(err, "env var for jaeger sampler param is not valid")
+			return nil, errors.Wrap(err, "failed to create application HTTP component")
 		}
+		cc = append(cc, cp)
 	}
 
-	info.UpsertConfig("jaeger-agent-sampler-param", prm)
-	log.Infof("setting up default tracing %s, %s with param %s", agent, tp, prm)
-	return trace.Setup(name, version, agent, tp, prmVal)
+	var oo []http.OptionFunc
+	if s.mgmtBind != "" {
+		oo = append(oo, http.BindAddress(s.mgmtBind))
+	}
+	cp, err := s.createHTTPComponent(s.mgmtPort, nil, oo...)
+	if err != nil {
+		return nil, err
+	}
+	return append(cc, cp), nil
 }
 
-func (s *Service) createHTTPComponent() (Component, error) {
-	var err error
-	var portVal = int64(50000)
-	port, ok := os.LookupEnv("PATRON_HTTP_DEFAULT_PORT")
-	if ok {
-		portVal, err = strconv.ParseInt(port, 10, 64)
-		if err != nil {
-			return nil, errors.Wrap(err, "env var for HTTP default port is not valid")
-		}
-	}
-	port = strconv.FormatInt(portVal, 10)
-	log.Infof("creating default HTTP component at port %s", port)
+// createHTTPComponent creates a HTTP component hosting the management endpoints along with the provided routes.
+func (s *Service) createHTTPComponent(port int, routes []http.Route, oo ...http.OptionFunc) (Component, error) {
+	log.Infof("creating default HTTP component at port %d", port)
 
 	options := []http.OptionFunc{
-		http.Port(int(portVal)),
+		http.Port(port),
+		http.ShutdownTimeout(s.httpShutdownTimeout()),
 	}
+	options = append(options, oo...)
 
 	if s.hcf != nil {
-		options = append(options, http.HealthCheck(s.hcf))
+		options = append(options, http.HealthCheck(s.healthCheck))
 	}
-
-	if s.routes != nil {
-		options = append(options, http.Routes(s.routes))
+	if s.noProf {
+		options = append(options, http.DisableProfiling())
 	}
+	if s.mgmtAuth != nil {
+		options = append(options, http.ManagementAuth(s.mgmtAuth))
+	}
+
+	rr := append([]http.Route{}, routes...)
+	rr = append(rr, s.reloadRoute())
+	rr = append(rr, s.logLevelRoutes()...)
+	options = append(options, http.Routes(rr))
 
 	cp, err := http.New(options...)
 	if err != nil {
@@ -213,3 +493,11 @@ func (s *Service) createHTTPComponent() (Component, error) {
 
 	return cp, nil
 }
+
+// httpShutdownTimeout returns the time the default HTTP component is allowed to drain in-flight requests.
+func (s *Service) httpShutdownTimeout() time.Duration {
+	if s.cmpShutdownTimeout > 0 && s.cmpShutdownTimeout < s.shutdownTimeout {
+		return s.cmpShutdownTimeout
+	}
+	return s.shutdownTimeout
+}
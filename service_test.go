@@ -2,10 +2,14 @@ package patron
 
 import (
 	"context"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/health"
 	"github.com/mantzas/patron/sync/http"
 )
 
@@ -38,6 +42,33 @@ func TestNewServer(t *testing.T) {
 	}
 }
 
+func TestNewServer_ManagementListener(t *testing.T) {
+	route := http.NewRoute("/", "GET", nil, true, nil)
+	tests := []struct {
+		name       string
+		oo         []OptionFunc
+		components int
+		wantErr    bool
+	}{
+		{"default HTTP component", []OptionFunc{Routes([]http.Route{route})}, 1, false},
+		{"management only", []OptionFunc{ManagementListener("127.0.0.1", 50001)}, 1, false},
+		{"application and management", []OptionFunc{Routes([]http.Route{route}), ManagementListener("", 50001)}, 2, false},
+		{"failed same port", []OptionFunc{ManagementListener("", 50000)}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New("test", "", tt.oo...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got.cps, tt.components)
+			}
+		})
+	}
+}
+
 func TestServer_Run_Shutdown(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -61,6 +92,149 @@ func TestServer_Run_Shutdown(t *testing.T) {
 	}
 }
 
+func TestService_readinessCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		ready   bool
+		hs      http.HealthStatus
+		wantErr bool
+	}{
+		{"ready", true, http.Healthy, false},
+		{"ready and degraded", true, http.Degraded, false},
+		{"not ready", false, http.Healthy, true},
+		{"initializing", true, http.Initializing, true},
+		{"unhealthy", true, http.Unhealthy, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := tt.hs
+			s, err := New("test", "", HealthCheck(func() http.HealthStatus { return hs }))
+			assert.NoError(t, err)
+			s.cps = nil
+			s.setReady(tt.ready)
+			err = s.readinessCheck(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_healthCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		hs   http.HealthStatus
+		cp   Component
+		want http.HealthStatus
+	}{
+		{"healthy", http.Healthy, &reportingComponent{status: health.Up}, http.Healthy},
+		{"component down", http.Healthy, &reportingComponent{status: health.Down}, http.Unhealthy},
+		{"health check initializing", http.Initializing, &reportingComponent{status: health.Up}, http.Initializing},
+		{"component without health report", http.Healthy, &testComponent{}, http.Healthy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := tt.hs
+			s, err := New("test", "", HealthCheck(func() http.HealthStatus { return hs }), Components(tt.cp))
+			assert.NoError(t, err)
+			s.cps = s.cps[:1]
+			assert.Equal(t, tt.want, s.healthCheck())
+		})
+	}
+}
+
+func TestServer_Run_Shutdown_Order(t *testing.T) {
+	var mu sync.Mutex
+	var stopped []string
+	cc := make([]Component, 3)
+	for i, n := range []string{"first", "second", "third"} {
+		cc[i] = &blockingComponent{name: n, onStop: func(name string) {
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = append(stopped, name)
+		}}
+	}
+	s, err := New("test", "", Components(cc...))
+	assert.NoError(t, err)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.termSig <- syscall.SIGTERM
+	}()
+	assert.NoError(t, s.Run())
+	assert.Equal(t, []string{"first", "second", "third"}, stopped)
+}
+
+func TestServer_Run_Shutdown_MissedDeadline(t *testing.T) {
+	s, err := New("test", "",
+		Components(&blockingComponent{name: "stuck", ignoreCancel: true}),
+		ShutdownTimeout(time.Second),
+		ComponentShutdownTimeout(50*time.Millisecond),
+	)
+	assert.NoError(t, err)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.termSig <- syscall.SIGTERM
+	}()
+	err = s.Run()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "component blocking[0] missed its shutdown deadline")
+}
+
+func TestService_setDrainTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		cmpTimeout time.Duration
+		remaining  time.Duration
+		wantMax    time.Duration
+		wantMin    time.Duration
+	}{
+		{name: "remaining grace period", remaining: time.Second, wantMin: 900 * time.Millisecond, wantMax: time.Second},
+		{name: "component timeout", cmpTimeout: 100 * time.Millisecond, remaining: time.Second, wantMin: 100 * time.Millisecond, wantMax: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Service{cmpShutdownTimeout: tt.cmpTimeout}
+			ctx, cnl := context.WithTimeout(context.Background(), tt.remaining)
+			defer cnl()
+			dc := &drainingComponent{}
+			s.setDrainTimeout(ctx, dc)
+			assert.True(t, dc.timeout >= tt.wantMin && dc.timeout <= tt.wantMax, dc.timeout.String())
+		})
+	}
+}
+
+type drainingComponent struct {
+	blockingComponent
+	timeout time.Duration
+}
+
+func (dc *drainingComponent) SetShutdownTimeout(d time.Duration) {
+	dc.timeout = d
+}
+
+type blockingComponent struct {
+	name         string
+	ignoreCancel bool
+	onStop       func(string)
+}
+
+func (bc blockingComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	if bc.ignoreCancel {
+		time.Sleep(time.Second)
+	}
+	if bc.onStop != nil {
+		bc.onStop(bc.name)
+	}
+	return nil
+}
+
+func (bc blockingComponent) Info() map[string]interface{} {
+	return map[string]interface{}{"type": "blocking"}
+}
+
 type testComponent struct {
 	errorRunning bool
 }
@@ -75,3 +249,12 @@ func (ts testComponent) Run(ctx context.Context) error {
 func (ts testComponent) Info() map[string]interface{} {
 	return map[string]interface{}{"type": "mock"}
 }
+
+type reportingComponent struct {
+	testComponent
+	status health.Status
+}
+
+func (rc reportingComponent) Health() health.Status {
+	return rc.status
+}
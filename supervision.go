@@ -0,0 +1,181 @@
+package patron
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mantzas/patron/encoding/json"
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/reliability/retry"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	maxRestartBackoff = time.Minute
+)
+
+var (
+	componentRestarts *prometheus.CounterVec
+	componentDegraded *prometheus.GaugeVec
+)
+
+func init() {
+	componentRestarts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "service",
+			Subsystem: "component",
+			Name:      "restarts",
+			Help:      "Component restarts, classified by component",
+		},
+		[]string{"component"},
+	)
+	componentDegraded = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "service",
+			Subsystem: "component",
+			Name:      "degraded",
+			Help:      "Component degraded state, classified by component",
+		},
+		[]string{"component"},
+	)
+	prometheus.MustRegister(componentRestarts, componentDegraded)
+}
+
+// SupervisionPolicy type definition.
+type SupervisionPolicy int
+
+const (
+	// FatalPolicy shuts down the service when the component exits.
+	FatalPolicy SupervisionPolicy = 0
+	// RestartPolicy restarts the component with backoff and shuts down the service when the restarts are exhausted.
+	RestartPolicy SupervisionPolicy = 1
+	// IgnorePolicy ignores the exit of the component and marks the service as degraded.
+	IgnorePolicy SupervisionPolicy = 2
+)
+
+func (sp SupervisionPolicy) String() string {
+	switch sp {
+	case FatalPolicy:
+		return "FatalPolicy"
+	case RestartPolicy:
+		return "RestartPolicy"
+	case IgnorePolicy:
+		return "IgnorePolicy"
+	default:
+		return "N/A"
+	}
+}
+
+// Supervision defines how the service handles a component that exits while the service is running.
+// Restarts and Backoff apply only to the RestartPolicy. The backoff doubles after every restart, up to a minute.
+type Supervision struct {
+	Policy   SupervisionPolicy
+	Restarts int
+	Backoff  time.Duration
+}
+
+func (s Supervision) validate() error {
+	if s.Policy > IgnorePolicy || s.Policy < FatalPolicy {
+		return errors.New("invalid supervision policy provided")
+	}
+	if s.Restarts < 0 {
+		return errors.New("invalid restarts provided")
+	}
+	if s.Backoff < 0 {
+		return errors.New("invalid backoff provided")
+	}
+	return nil
+}
+
+// componentStatus holds the supervision state of a component.
+type componentStatus struct {
+	sync.Mutex
+	policy   SupervisionPolicy
+	restarts int
+	degraded bool
+	lastErr  error
+}
+
+func (cs *componentStatus) restart(err error) int {
+	cs.Lock()
+	defer cs.Unlock()
+	cs.restarts++
+	cs.lastErr = err
+	return cs.restarts
+}
+
+func (cs *componentStatus) restartCount() int {
+	cs.Lock()
+	defer cs.Unlock()
+	return cs.restarts
+}
+
+func (cs *componentStatus) degrade(err error) {
+	cs.Lock()
+	defer cs.Unlock()
+	cs.degraded = true
+	cs.lastErr = err
+}
+
+func (cs *componentStatus) isDegraded() bool {
+	cs.Lock()
+	defer cs.Unlock()
+	return cs.degraded
+}
+
+// MarshalJSON returns the current supervision state for the info endpoint.
+func (cs *componentStatus) MarshalJSON() ([]byte, error) {
+	cs.Lock()
+	defer cs.Unlock()
+	st := "running"
+	if cs.degraded {
+		st = "degraded"
+	}
+	v := map[string]interface{}{
+		"policy":   cs.policy.String(),
+		"restarts": cs.restarts,
+		"status":   st,
+	}
+	if cs.lastErr != nil {
+		v["last-error"] = cs.lastErr.Error()
+	}
+	return json.Encode(v)
+}
+
+// supervise runs the component applying the supervision policy whenever it exits while not being stopped.
+// It returns whether the exit should lead to the service shutting down along with the error of the component.
+func (r *runner) supervise() (bool, error) {
+	b := retry.Backoff{Initial: r.sup.Backoff, Max: maxRestartBackoff}
+	for {
+		err := r.runComponent()
+		if err != nil {
+			err = errors.Wrapf(err, "component %s failed", r.name)
+		}
+		if r.ctx.Err() != nil {
+			return true, err
+		}
+
+		switch r.sup.Policy {
+		case RestartPolicy:
+			if r.status.restartCount() >= r.sup.Restarts {
+				log.Errorf("component %s exhausted its %d restarts", r.name, r.sup.Restarts)
+				return true, err
+			}
+			n := r.status.restart(err)
+			componentRestarts.WithLabelValues(r.name).Inc()
+			wait := b.Wait(n - 1)
+			log.Errorf("component %s exited, restart %d/%d in %v: %v", r.name, n, r.sup.Restarts, wait, err)
+			if retry.Sleep(r.ctx, wait) != nil {
+				return true, nil
+			}
+		case IgnorePolicy:
+			r.status.degrade(err)
+			componentDegraded.WithLabelValues(r.name).Set(1)
+			log.Errorf("component %s exited and is ignored, service is degraded: %v", r.name, err)
+			return false, nil
+		default:
+			return true, err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package patron
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/sync/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupervisionPolicy_String(t *testing.T) {
+	tests := []struct {
+		name string
+		sp   SupervisionPolicy
+		want string
+	}{
+		{name: "FatalPolicy", sp: FatalPolicy, want: "FatalPolicy"},
+		{name: "RestartPolicy", sp: RestartPolicy, want: "RestartPolicy"},
+		{name: "IgnorePolicy", sp: IgnorePolicy, want: "IgnorePolicy"},
+		{name: "Not mapped", sp: -1, want: "N/A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.sp.String())
+		})
+	}
+}
+
+func TestServer_Run_RestartPolicy(t *testing.T) {
+	cp := &failingComponent{failures: 2}
+	s, err := New("test", "",
+		SupervisedComponents(Supervision{Policy: RestartPolicy, Restarts: 3, Backoff: time.Millisecond}, cp),
+	)
+	assert.NoError(t, err)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.termSig <- syscall.SIGTERM
+	}()
+	assert.NoError(t, s.Run())
+	assert.Equal(t, 3, cp.getRuns())
+	assert.Equal(t, 2, s.statuses[0].restartCount())
+}
+
+func TestServer_Run_RestartPolicy_Exhausted(t *testing.T) {
+	cp := &failingComponent{failures: 5}
+	s, err := New("test", "",
+		SupervisedComponents(Supervision{Policy: RestartPolicy, Restarts: 2, Backoff: time.Millisecond}, cp),
+	)
+	assert.NoError(t, err)
+	assert.Error(t, s.Run())
+	assert.Equal(t, 3, cp.getRuns())
+}
+
+func TestServer_Run_IgnorePolicy(t *testing.T) {
+	cp := &failingComponent{failures: 1}
+	s, err := New("test", "",
+		SupervisedComponents(Supervision{Policy: IgnorePolicy}, cp),
+	)
+	assert.NoError(t, err)
+	// the default HTTP component reports itself as down until it runs
+	assert.Equal(t, http.Unhealthy, s.healthCheck())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, http.Degraded, s.healthCheck())
+		s.termSig <- syscall.SIGTERM
+	}()
+	assert.NoError(t, s.Run())
+	assert.Equal(t, 1, cp.getRuns())
+	assert.True(t, s.statuses[0].isDegraded())
+}
+
+func TestComponentStatus_MarshalJSON(t *testing.T) {
+	cs := componentStatus{policy: IgnorePolicy}
+	b, err := cs.MarshalJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"policy":"IgnorePolicy","restarts":0,"status":"running"}`, string(b))
+	cs.degrade(errors.New("TEST"))
+	b, err = cs.MarshalJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"policy":"IgnorePolicy","restarts":0,"status":"degraded","last-error":"TEST"}`, string(b))
+}
+
+type failingComponent struct {
+	sync.Mutex
+	failures int
+	runs     int
+}
+
+func (fc *failingComponent) Run(ctx context.Context) error {
+	fc.Lock()
+	fc.runs++
+	fail := fc.runs <= fc.failures
+	fc.Unlock()
+	if fail {
+		return errors.New("failed to run component")
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (fc *failingComponent) getRuns() int {
+	fc.Lock()
+	defer fc.Unlock()
+	return fc.runs
+}
+
+func (fc *failingComponent) Info() map[string]interface{} {
+	return map[string]interface{}{"type": "failing"}
+}
@@ -2,13 +2,16 @@ package http
 
 import (
 	"context"
-	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mantzas/patron/health"
 	"github.com/mantzas/patron/log"
+	"github.com/mantzas/patron/sync/http/auth"
 )
 
 const (
@@ -16,6 +19,7 @@ const (
 	httpReadTimeout  = 5 * time.Second
 	httpWriteTimeout = 10 * time.Second
 	httpIdleTimeout  = 120 * time.Second
+	shutdownTimeout  = 30 * time.Second
 )
 
 var (
@@ -29,11 +33,17 @@ type Component struct {
 	httpPort         int
 	httpReadTimeout  time.Duration
 	httpWriteTimeout time.Duration
+	shutdownTimeout  time.Duration
 	info             map[string]interface{}
 	sync.Mutex
 	routes   []Route
 	certFile string
 	keyFile  string
+	bind     string
+	noMgmt   bool
+	noProf   bool
+	mgmtAuth auth.Authenticator
+	serving  bool
 }
 
 // New returns a new component.
@@ -43,6 +53,7 @@ func New(oo ...OptionFunc) (*Component, error) {
 		httpPort:         httpPort,
 		httpReadTimeout:  httpReadTimeout,
 		httpWriteTimeout: httpWriteTimeout,
+		shutdownTimeout:  shutdownTimeout,
 		routes:           []Route{},
 		info:             make(map[string]interface{}),
 	}
@@ -54,15 +65,28 @@ func New(oo ...OptionFunc) (*Component, error) {
 		}
 	}
 
-	c.routes = append(c.routes, healthCheckRoute(c.hc))
-	c.routes = append(c.routes, profilingRoutes()...)
-	c.routes = append(c.routes, metricRoute())
-	c.routes = append(c.routes, infoRoute())
+	if !c.noMgmt {
+		c.routes = append(c.routes, c.managementRoutes()...)
+	}
 
 	c.createInfo()
 	return &c, nil
 }
 
+// managementRoutes returns the health, liveness, readiness, metric, info and, unless disabled, profiling routes.
+// The management authenticator, if set, protects all of them except the health, liveness and readiness checks.
+func (c *Component) managementRoutes() []Route {
+	var rr []Route
+	if !c.noProf {
+		rr = append(rr, profilingRoutes()...)
+	}
+	rr = append(rr, metricRoute(), infoRoute())
+	for i := range rr {
+		rr[i].Auth = c.mgmtAuth
+	}
+	return append([]Route{healthCheckRoute(c.hc), livenessRoute(), readinessRoute()}, rr...)
+}
+
 // Info return information of the component.
 func (c *Component) Info() map[string]interface{} {
 	return c.info
@@ -75,24 +99,56 @@ func (c *Component) Run(ctx context.Context) error {
 	for i := 0; i < len(c.routes); i++ {
 		c.routes[i].Handler = Middleware(c.routes[i].Trace, c.routes[i].Auth, c.routes[i].Pattern, c.routes[i].Handler)
 	}
-	chFail := make(chan error)
+	chFail := make(chan error, 1)
 	srv := c.createHTTPServer()
 	go c.listenAndServe(srv, chFail)
+	c.serving = true
 	c.Unlock()
+	defer c.stopServing()
 
 	select {
 	case <-ctx.Done():
-		log.Info("shutting down component")
-		return srv.Shutdown(ctx)
+		c.Lock()
+		timeout := c.shutdownTimeout
+		c.Unlock()
+		log.Infof("shutting down component, draining in-flight requests for up to %v", timeout)
+		shCtx, cnl := context.WithTimeout(context.Background(), timeout)
+		defer cnl()
+		return srv.Shutdown(shCtx)
 	case err := <-chFail:
 		return err
 	}
 }
 
+// SetShutdownTimeout sets the time the component has to drain in-flight requests, e.g. to the remaining
+// grace period of the service when the component is stopped.
+func (c *Component) SetShutdownTimeout(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.shutdownTimeout = d
+}
+
+func (c *Component) stopServing() {
+	c.Lock()
+	defer c.Unlock()
+	c.serving = false
+}
+
+// Health reports the component as down when it is not serving.
+func (c *Component) Health() health.Status {
+	c.Lock()
+	defer c.Unlock()
+	if c.serving {
+		return health.Up
+	}
+	return health.Down
+}
+
 func (c *Component) listenAndServe(srv *http.Server, ch chan<- error) {
 	if c.certFile != "" && c.keyFile != "" {
 		log.Infof("HTTPS component listening on port %d", c.httpPort)
 		ch <- srv.ListenAndServeTLS(c.certFile, c.keyFile)
+		return
 	}
 
 	log.Infof("HTTP component listening on port %d", c.httpPort)
@@ -107,7 +163,7 @@ func (c *Component) createHTTPServer() *http.Server {
 		log.Debugf("added route %s %s", route.Method, route.Pattern)
 	}
 	return &http.Server{
-		Addr:         fmt.Sprintf(":%d", c.httpPort),
+		Addr:         net.JoinHostPort(c.bind, strconv.Itoa(c.httpPort)),
 		ReadTimeout:  c.httpReadTimeout,
 		WriteTimeout: c.httpWriteTimeout,
 		IdleTimeout:  httpIdleTimeout,
@@ -121,6 +177,16 @@ func (c *Component) createInfo() {
 	c.info["read-timeout"] = c.httpReadTimeout.String()
 	c.info["write-timeout"] = c.httpWriteTimeout.String()
 	c.info["idle-timeout"] = httpIdleTimeout.String()
+	c.info["shutdown-timeout"] = c.shutdownTimeout.String()
+	if c.bind != "" {
+		c.info["bind"] = c.bind
+	}
+	if c.noMgmt {
+		c.info["management"] = false
+	}
+	if c.noProf {
+		c.info["profiling"] = false
+	}
 	if c.keyFile != "" && c.certFile != "" {
 		c.info["type"] = "https"
 		c.info["key-file"] = c.keyFile
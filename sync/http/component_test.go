@@ -2,11 +2,13 @@ package http
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/health"
 )
 
 func ErrorOption() OptionFunc {
@@ -43,6 +45,7 @@ func TestComponent_ListenAndServe_DefaultRoutes_Shutdown(t *testing.T) {
 	rr := []Route{NewRoute("/", "GET", nil, true, nil)}
 	s, err := New(Routes(rr), Port(50003))
 	assert.NoError(t, err)
+	assert.Equal(t, health.Down, s.Health())
 	done := make(chan bool)
 	ctx, cnl := context.WithCancel(context.Background())
 	go func() {
@@ -50,9 +53,11 @@ func TestComponent_ListenAndServe_DefaultRoutes_Shutdown(t *testing.T) {
 		done <- true
 	}()
 	time.Sleep(100 * time.Millisecond)
-	assert.Len(t, s.routes, 15)
+	assert.Len(t, s.routes, 17)
+	assert.Equal(t, health.Up, s.Health())
 	cnl()
 	assert.True(t, <-done)
+	assert.Equal(t, health.Down, s.Health())
 }
 
 func TestComponent_ListenAndServeTLS_DefaultRoutes_Shutdown(t *testing.T) {
@@ -66,11 +71,49 @@ func TestComponent_ListenAndServeTLS_DefaultRoutes_Shutdown(t *testing.T) {
 		done <- true
 	}()
 	time.Sleep(100 * time.Millisecond)
-	assert.Len(t, s.routes, 15)
+	assert.Len(t, s.routes, 17)
 	cnl()
 	assert.True(t, <-done)
 }
 
+func TestComponent_Shutdown_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	h := func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}
+	rr := []Route{NewRouteRaw("/slow", http.MethodGet, h, false)}
+	s, err := New(Routes(rr), Port(50007), ShutdownTimeout(time.Second))
+	assert.NoError(t, err)
+	done := make(chan error)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		done <- s.Run(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	chStatus := make(chan int)
+	go func() {
+		rsp, err := http.Get("http://localhost:50007/slow")
+		if err != nil {
+			chStatus <- 0
+			return
+		}
+		chStatus <- rsp.StatusCode
+	}()
+	<-started
+	cnl()
+	assert.Equal(t, http.StatusCreated, <-chStatus)
+	assert.NoError(t, <-done)
+}
+
+func TestComponent_SetShutdownTimeout(t *testing.T) {
+	s, err := New(ShutdownTimeout(time.Second))
+	assert.NoError(t, err)
+	s.SetShutdownTimeout(time.Millisecond)
+	assert.Equal(t, time.Millisecond, s.shutdownTimeout)
+}
+
 func TestInfo(t *testing.T) {
 	rr := []Route{NewRoute("/", "GET", nil, true, nil)}
 	s, err := New(Routes(rr), Secure("testdata/server.pem", "testdata/server.key"), Port(50005))
@@ -81,6 +124,7 @@ func TestInfo(t *testing.T) {
 	expected["read-timeout"] = httpReadTimeout.String()
 	expected["write-timeout"] = httpWriteTimeout.String()
 	expected["idle-timeout"] = httpIdleTimeout.String()
+	expected["shutdown-timeout"] = shutdownTimeout.String()
 	expected["key-file"] = "testdata/server.key"
 	expected["cert-file"] = "testdata/server.pem"
 	assert.Equal(t, expected, s.Info())
@@ -93,6 +137,35 @@ func TestComponent_ListenAndServeTLS_FailsInvalidCerts(t *testing.T) {
 	assert.Error(t, s.Run(context.Background()))
 }
 
+func TestComponent_managementRoutes(t *testing.T) {
+	a := &MockAuthenticator{success: true}
+	tests := []struct {
+		name    string
+		options []OptionFunc
+		routes  int
+	}{
+		{"default", []OptionFunc{}, 16},
+		{"profiling disabled", []OptionFunc{DisableProfiling()}, 5},
+		{"management disabled", []OptionFunc{DisableManagementRoutes()}, 0},
+		{"management auth", []OptionFunc{ManagementAuth(a)}, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.options...)
+			assert.NoError(t, err)
+			assert.Len(t, s.routes, tt.routes)
+			for _, r := range s.routes {
+				switch r.Pattern {
+				case "/health", "/live", "/ready":
+					assert.Nil(t, r.Auth)
+				default:
+					assert.Equal(t, s.mgmtAuth, r.Auth)
+				}
+			}
+		})
+	}
+}
+
 func Test_createHTTPServer(t *testing.T) {
 	cmp := Component{
 		httpPort:         10000,
@@ -105,3 +178,22 @@ func Test_createHTTPServer(t *testing.T) {
 	assert.Equal(t, 5*time.Second, s.ReadTimeout)
 	assert.Equal(t, 10*time.Second, s.WriteTimeout)
 }
+
+func Test_createHTTPServer_BindAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		bind string
+		want string
+	}{
+		{name: "all interfaces", bind: "", want: ":10000"},
+		{name: "IPv4", bind: "127.0.0.1", want: "127.0.0.1:10000"},
+		{name: "IPv6", bind: "::1", want: "[::1]:10000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmp := Component{httpPort: 10000, bind: tt.bind}
+			s := cmp.createHTTPServer()
+			assert.Equal(t, tt.want, s.Addr)
+		})
+	}
+}
@@ -2,6 +2,11 @@ package http
 
 import (
 	"net/http"
+
+	"github.com/mantzas/patron/encoding"
+	"github.com/mantzas/patron/encoding/json"
+	"github.com/mantzas/patron/health"
+	"github.com/mantzas/patron/log"
 )
 
 // HealthStatus type representing the health of the service via HTTP component.
@@ -14,6 +19,8 @@ const (
 	Healthy HealthStatus = 1
 	// Unhealthy represents a state defining a unhealthy state.
 	Unhealthy HealthStatus = 2
+	// Degraded represents a state where the service is operational but some of its functionality is not.
+	Degraded HealthStatus = 3
 )
 
 // HealthCheckFunc defines a function type for implementing a health check.
@@ -25,7 +32,7 @@ func healthCheckRoute(hcf HealthCheckFunc) Route {
 		switch hcf() {
 		case Initializing:
 			w.WriteHeader(http.StatusServiceUnavailable)
-		case Healthy:
+		case Healthy, Degraded:
 			w.WriteHeader(http.StatusOK)
 		case Unhealthy:
 			w.WriteHeader(http.StatusInternalServerError)
@@ -35,3 +42,32 @@ func healthCheckRoute(hcf HealthCheckFunc) Route {
 	}
 	return NewRouteRaw("/health", http.MethodGet, f, false)
 }
+
+func probeRoute(p string, k health.Kind) Route {
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		rep := health.Evaluate(r.Context(), k)
+		body, err := json.Encode(rep)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Add(encoding.ContentTypeHeader, json.TypeCharset)
+		if rep.Status == health.Down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, err = w.Write(body)
+		if err != nil {
+			log.Errorf("failed to write %s report: %v", k, err)
+		}
+	}
+	return NewRouteRaw(p, http.MethodGet, f, false)
+}
+
+func livenessRoute() Route {
+	return probeRoute("/live", health.Liveness)
+}
+
+func readinessRoute() Route {
+	return probeRoute("/ready", health.Readiness)
+}
@@ -1,10 +1,13 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/mantzas/patron/health"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,6 +20,7 @@ func Test_healthCheckRoute(t *testing.T) {
 		{"healthy", func() HealthStatus { return Healthy }, http.StatusOK},
 		{"initializing", func() HealthStatus { return Initializing }, http.StatusServiceUnavailable},
 		{"unhealthy", func() HealthStatus { return Unhealthy }, http.StatusInternalServerError},
+		{"degraded", func() HealthStatus { return Degraded }, http.StatusOK},
 		{"default", func() HealthStatus { return 10 }, http.StatusOK},
 	}
 	for _, tt := range tests {
@@ -30,3 +34,28 @@ func Test_healthCheckRoute(t *testing.T) {
 		})
 	}
 }
+
+func Test_probeRoutes(t *testing.T) {
+	err := health.Register("http-test", health.Readiness, func(ctx context.Context) error { return errors.New("TEST") })
+	assert.NoError(t, err)
+	defer health.Deregister("http-test")
+	tests := []struct {
+		name  string
+		route Route
+		want  int
+		body  string
+	}{
+		{"liveness", livenessRoute(), http.StatusOK, `"status":"up"`},
+		{"readiness", readinessRoute(), http.StatusServiceUnavailable, `"status":"down"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", tt.route.Pattern, nil)
+			assert.NoError(t, err)
+			tt.route.Handler(resp, req)
+			assert.Equal(t, tt.want, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.body)
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package http
 
 import (
+	"net"
 	"time"
 
 	"github.com/mantzas/patron/errors"
+	"github.com/mantzas/patron/sync/http/auth"
 )
 
 // OptionFunc defines a option func for the HTTP component.
@@ -29,6 +31,17 @@ func Timeouts(read, write time.Duration) OptionFunc {
 	}
 }
 
+// ShutdownTimeout option for setting the time the HTTP component has to drain in-flight requests on shutdown.
+func ShutdownTimeout(d time.Duration) OptionFunc {
+	return func(s *Component) error {
+		if d <= 0 {
+			return errors.New("shutdown timeout must be positive")
+		}
+		s.shutdownTimeout = d
+		return nil
+	}
+}
+
 // Routes option for setting the routes of the HTTP component.
 func Routes(rr []Route) OptionFunc {
 	return func(s *Component) error {
@@ -65,3 +78,41 @@ func Secure(certFile, keyFile string) OptionFunc {
 		return nil
 	}
 }
+
+// BindAddress option for setting the address the HTTP component listens on, e.g. 127.0.0.1.
+func BindAddress(addr string) OptionFunc {
+	return func(s *Component) error {
+		if net.ParseIP(addr) == nil {
+			return errors.New("invalid bind address")
+		}
+		s.bind = addr
+		return nil
+	}
+}
+
+// DisableManagementRoutes option for not adding the health, metric, info and profiling routes to the HTTP component.
+func DisableManagementRoutes() OptionFunc {
+	return func(s *Component) error {
+		s.noMgmt = true
+		return nil
+	}
+}
+
+// DisableProfiling option for not adding the profiling routes to the HTTP component.
+func DisableProfiling() OptionFunc {
+	return func(s *Component) error {
+		s.noProf = true
+		return nil
+	}
+}
+
+// ManagementAuth option for protecting the metric, info and profiling routes of the HTTP component with a authenticator.
+func ManagementAuth(a auth.Authenticator) OptionFunc {
+	return func(s *Component) error {
+		if a == nil {
+			return errors.New("authenticator is required")
+		}
+		s.mgmtAuth = a
+		return nil
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mantzas/patron/sync/http/auth"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -114,3 +115,85 @@ func TestTimeouts(t *testing.T) {
 	assert.Equal(t, 2*time.Second, c.httpReadTimeout)
 	assert.Equal(t, 3*time.Second, c.httpWriteTimeout)
 }
+
+func TestShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{"success", time.Second, false},
+		{"error for zero timeout", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := ShutdownTimeout(tt.timeout)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.timeout, c.shutdownTimeout)
+			}
+		})
+	}
+}
+
+func TestBindAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr bool
+	}{
+		{"success", "127.0.0.1", false},
+		{"error for invalid address", "localhost", true},
+		{"error for empty address", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := BindAddress(tt.addr)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.addr, c.bind)
+			}
+		})
+	}
+}
+
+func TestDisableManagementRoutes(t *testing.T) {
+	c := Component{}
+	assert.NoError(t, DisableManagementRoutes()(&c))
+	assert.True(t, c.noMgmt)
+}
+
+func TestDisableProfiling(t *testing.T) {
+	c := Component{}
+	assert.NoError(t, DisableProfiling()(&c))
+	assert.True(t, c.noProf)
+}
+
+func TestManagementAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    auth.Authenticator
+		wantErr bool
+	}{
+		{"success", &MockAuthenticator{success: true}, false},
+		{"error for missing authenticator", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Component{}
+			err := ManagementAuth(tt.auth)(&c)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.auth, c.mgmtAuth)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 type Message struct {
 	contentType string
 	body        []byte
+	headers     map[string]string
 }
 
 // NewMessage creates a new message.
@@ -25,6 +26,12 @@ func NewMessage(ct string, body []byte) *Message {
 	return &Message{contentType: ct, body: body}
 }
 
+// NewMessageWithHeaders creates a new message with headers.
+// Tracing headers are injected when publishing and take precedence over the provided ones.
+func NewMessageWithHeaders(ct string, body []byte, hh map[string]string) *Message {
+	return &Message{contentType: ct, body: body, headers: hh}
+}
+
 // NewJSONMessage creates a new message with a JSON encoded body.
 func NewJSONMessage(d interface{}) (*Message, error) {
 	body, err := json.Encode(d)
@@ -127,6 +134,9 @@ func (tc *TracedPublisher) Publish(ctx context.Context, msg *Message) error {
 		ContentType: msg.contentType,
 		Body:        msg.body,
 	}
+	for k, v := range msg.headers {
+		p.Headers[k] = v
+	}
 
 	c := amqpHeadersCarrier(p.Headers)
 	err := sp.Tracer().Inject(sp.Context(), opentracing.TextMap, c)
@@ -14,6 +14,13 @@ func TestNewMessage(t *testing.T) {
 	assert.Equal(t, []byte("test"), m.body)
 }
 
+func TestNewMessageWithHeaders(t *testing.T) {
+	m := NewMessageWithHeaders("xxx", []byte("test"), map[string]string{"key": "value"})
+	assert.Equal(t, "xxx", m.contentType)
+	assert.Equal(t, []byte("test"), m.body)
+	assert.Equal(t, map[string]string{"key": "value"}, m.headers)
+}
+
 func TestNewJSONMessage(t *testing.T) {
 	m, err := NewJSONMessage("xxx")
 	assert.NoError(t, err)
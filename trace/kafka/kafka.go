@@ -13,8 +13,10 @@ import (
 
 // Message abstraction of a Kafka message.
 type Message struct {
-	topic string
-	body  []byte
+	topic   string
+	key     []byte
+	body    []byte
+	headers map[string]string
 }
 
 // NewMessage creates a new message.
@@ -22,6 +24,17 @@ func NewMessage(t string, b []byte) *Message {
 	return &Message{topic: t, body: b}
 }
 
+// NewMessageWithHeaders creates a new message with headers.
+// Tracing headers are injected when sending and take precedence over the provided ones.
+func NewMessageWithHeaders(t string, b []byte, hh map[string]string) *Message {
+	return &Message{topic: t, body: b, headers: hh}
+}
+
+// NewMessageWithKey creates a new message with a key, which selects its partition, and headers.
+func NewMessageWithKey(t string, k, b []byte, hh map[string]string) *Message {
+	return &Message{topic: t, key: k, body: b, headers: hh}
+}
+
 // NewJSONMessage creates a new message with a JSON encoded body.
 func NewJSONMessage(t string, d interface{}) (*Message, error) {
 	b, err := json.Encode(d)
@@ -106,15 +119,91 @@ func (ap *AsyncProducer) propagateError() {
 	}
 }
 
+// SyncProducer defines a sync Kafka producer, which waits for the brokers to acknowledge every message.
+type SyncProducer struct {
+	prod sarama.SyncProducer
+	tag  opentracing.Tag
+}
+
+// NewSyncProducer creates a new sync producer with default configuration.
+func NewSyncProducer(brokers []string, oo ...OptionFunc) (*SyncProducer, error) {
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V0_11_0_0
+	cfg.Producer.Return.Successes = true
+
+	// the options apply to the configuration, which is shared with the async producer
+	ap := AsyncProducer{cfg: cfg}
+	for _, o := range oo {
+		err := o(&ap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	prod, err := sarama.NewSyncProducer(brokers, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create sync producer")
+	}
+	return &SyncProducer{prod: prod, tag: opentracing.Tag{Key: "type", Value: "sync"}}, nil
+}
+
+// Send a message to a topic, returning once it has been acknowledged by the brokers.
+func (sp *SyncProducer) Send(ctx context.Context, msg *Message) error {
+	span, _ := trace.ChildSpan(
+		ctx,
+		trace.ComponentOpName(trace.KafkaSyncProducerComponent, msg.topic),
+		trace.KafkaSyncProducerComponent,
+		ext.SpanKindProducer,
+		sp.tag,
+		opentracing.Tag{Key: "topic", Value: msg.topic},
+	)
+	pm, err := createProducerMessage(msg, span)
+	if err != nil {
+		trace.SpanError(span)
+		return err
+	}
+	_, _, err = sp.prod.SendMessage(pm)
+	if err != nil {
+		trace.SpanError(span)
+		return errors.Wrap(err, "failed to send message")
+	}
+	trace.SpanSuccess(span)
+	return nil
+}
+
+// Error returns a nil channel, since the errors are returned by Send.
+func (sp *SyncProducer) Error() <-chan error {
+	return nil
+}
+
+// Close gracefully the producer.
+func (sp *SyncProducer) Close() error {
+	return errors.Wrap(sp.prod.Close(), "failed to close sync producer")
+}
+
 func createProducerMessage(msg *Message, sp opentracing.Span) (*sarama.ProducerMessage, error) {
 	c := kafkaHeadersCarrier{}
 	err := sp.Tracer().Inject(sp.Context(), opentracing.TextMap, &c)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to inject tracing headers")
 	}
+	injected := make(map[string]bool, len(c))
+	for _, h := range c {
+		injected[string(h.Key)] = true
+	}
+	for k, v := range msg.headers {
+		if !injected[k] {
+			c.Set(k, v)
+		}
+	}
+	var key sarama.Encoder
+	if len(msg.key) > 0 {
+		key = sarama.ByteEncoder(msg.key)
+	}
 	return &sarama.ProducerMessage{
 		Topic:   msg.topic,
-		Key:     nil,
+		Key:     key,
 		Value:   sarama.ByteEncoder(msg.body),
 		Headers: c,
 	}, nil
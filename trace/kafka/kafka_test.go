@@ -6,6 +6,7 @@ import (
 
 	"github.com/Shopify/sarama"
 	"github.com/mantzas/patron/trace"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
 	jaeger "github.com/uber/jaeger-client-go"
 )
@@ -16,6 +17,46 @@ func TestNewMessage(t *testing.T) {
 	assert.Equal(t, []byte("TEST"), m.body)
 }
 
+func TestNewMessageWithHeaders(t *testing.T) {
+	m := NewMessageWithHeaders("TOPIC", []byte("TEST"), map[string]string{"key": "value"})
+	assert.Equal(t, "TOPIC", m.topic)
+	assert.Equal(t, []byte("TEST"), m.body)
+	assert.Equal(t, map[string]string{"key": "value"}, m.headers)
+}
+
+func TestNewMessageWithKey(t *testing.T) {
+	m := NewMessageWithKey("TOPIC", []byte("KEY"), []byte("TEST"), map[string]string{"key": "value"})
+	assert.Equal(t, "TOPIC", m.topic)
+	assert.Equal(t, []byte("KEY"), m.key)
+	assert.Equal(t, []byte("TEST"), m.body)
+	assert.Equal(t, map[string]string{"key": "value"}, m.headers)
+}
+
+func Test_createProducerMessage_Key(t *testing.T) {
+	sp := mocktracer.New().StartSpan("test")
+	pm, err := createProducerMessage(NewMessage("TOPIC", []byte("TEST")), sp)
+	assert.NoError(t, err)
+	assert.Nil(t, pm.Key)
+	pm, err = createProducerMessage(NewMessageWithKey("TOPIC", []byte("KEY"), []byte("TEST"), nil), sp)
+	assert.NoError(t, err)
+	assert.Equal(t, sarama.ByteEncoder("KEY"), pm.Key)
+}
+
+func Test_createProducerMessage_Headers(t *testing.T) {
+	mtr := mocktracer.New()
+	sp := mtr.StartSpan("test")
+	msg := NewMessageWithHeaders("TOPIC", []byte("TEST"), map[string]string{"key": "value", "mockpfx-ids-traceid": "1"})
+	pm, err := createProducerMessage(msg, sp)
+	assert.NoError(t, err)
+	hh := make(map[string][]string)
+	for _, h := range pm.Headers {
+		hh[string(h.Key)] = append(hh[string(h.Key)], string(h.Value))
+	}
+	assert.Equal(t, []string{"value"}, hh["key"])
+	assert.Len(t, hh["mockpfx-ids-traceid"], 1)
+	assert.NotEqual(t, "1", hh["mockpfx-ids-traceid"][0])
+}
+
 func TestNewJSONMessage(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -74,6 +115,48 @@ func TestAsyncProducer_SendMessage_Close(t *testing.T) {
 	ap.Close()
 }
 
+func TestSyncProducer_Failure(t *testing.T) {
+	got, err := NewSyncProducer([]string{})
+	assert.Error(t, err)
+	assert.Nil(t, got)
+	got, err = NewSyncProducer([]string{"xxx"}, Version("xxxx"))
+	assert.Error(t, err)
+	assert.Nil(t, got)
+}
+
+func TestSyncProducer_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		kerr    sarama.KError
+		wantErr bool
+	}{
+		{name: "success", kerr: sarama.ErrNoError},
+		{name: "failure", kerr: sarama.ErrInvalidMessage, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := sarama.NewMockBroker(t, 1)
+			defer broker.Close()
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(t).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader("TOPIC", 0, broker.BrokerID()),
+				"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3).SetError("TOPIC", 0, tt.kerr),
+			})
+			sp, err := NewSyncProducer([]string{broker.Addr()}, Version(sarama.V0_11_0_0.String()))
+			assert.NoError(t, err)
+			err = sp.Send(context.Background(), NewMessageWithKey("TOPIC", []byte("KEY"), []byte("TEST"), nil))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Nil(t, sp.Error())
+			assert.NoError(t, sp.Close())
+		})
+	}
+}
+
 func createKafkaBroker(t *testing.T, retError bool) *sarama.MockBroker {
 	lead := sarama.NewMockBroker(t, 2)
 	metadataResponse := new(sarama.MetadataResponse)